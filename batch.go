@@ -0,0 +1,80 @@
+package automata
+
+// accumulate the gradients of the given neuron's input weights and bias.
+func (t *LookupTable) accumulate(n *Neuron) {
+	for _, connID := range n.Inputs {
+		t.accumulateWeight(connID, n.gradient(t.GetConnection(connID)))
+	}
+	t.accumulateBias(n.ID, n.ErrorResponsibility)
+}
+
+// accumulateWeight adds to the gradient of the given connection's weight.
+func (t *LookupTable) accumulateWeight(connID ConnID, gradient float64) {
+	if int(connID) > (len(t.WeightGradients) - 1) {
+		diff := int(connID) - (len(t.WeightGradients) - 1)
+		t.WeightGradients = append(t.WeightGradients, make([]float64, diff)...)
+	}
+	t.WeightGradients[connID] += gradient
+}
+
+// accumulateBias adds to the gradient of the given neuron's bias, and marks the neuron as part of the batch.
+func (t *LookupTable) accumulateBias(id NeuronID, gradient float64) {
+	if int(id) > (len(t.BiasGradients) - 1) {
+		diff := int(id) - (len(t.BiasGradients) - 1)
+		t.BiasGradients = append(t.BiasGradients, make([]float64, diff)...)
+		t.inBatch = append(t.inBatch, make([]bool, diff)...)
+	}
+	t.BiasGradients[id] += gradient
+	if !t.inBatch[id] {
+		t.inBatch[id] = true
+		t.batchNeurons = append(t.batchNeurons, id)
+	}
+}
+
+// addGradients adds the gradients accumulated by the table of a clone of a network using this table, such as a
+// replica, to the gradients accumulated by this table. 'ids' maps between the IDs in the two tables. Connections
+// which were not cloned get no gradient. The other table's gradients are then reset.
+func (t *LookupTable) addGradients(other *LookupTable, ids cloneIDs) {
+	for _, nid := range other.batchNeurons {
+		neuron := t.GetNeuron(ids.neurons[nid])
+		for _, connID := range neuron.Inputs {
+			var gradient float64
+			if int(connID) < len(ids.conns) && ids.conns[connID] >= 0 {
+				gradient = other.WeightGradients[ids.conns[connID]]
+			}
+			t.accumulateWeight(connID, gradient)
+		}
+		t.accumulateBias(neuron.ID, other.BiasGradients[nid])
+	}
+	other.ResetGradients()
+}
+
+// ApplyGradients updates weights and biases using the gradients accumulated since they were last applied. The
+// gradients are averaged over 'count', which should be the number of samples which were propagated. Only the
+// parameters of neurons which have propagated an error are updated. The accumulated gradients are then reset.
+func (t *LookupTable) ApplyGradients(rate float64, count int) {
+	if count < 1 {
+		count = 1
+	}
+	for _, nid := range t.batchNeurons {
+		neuron := t.GetNeuron(nid)
+		for _, connID := range neuron.Inputs {
+			conn := t.GetConnection(connID)
+			conn.Weight += t.weightDelta(connID, rate, t.WeightGradients[connID]/float64(count))
+		}
+		neuron.Bias += t.biasDelta(nid, rate, t.BiasGradients[nid]/float64(count))
+	}
+	t.ResetGradients()
+}
+
+// ResetGradients discards any accumulated gradients without applying them.
+func (t *LookupTable) ResetGradients() {
+	for _, nid := range t.batchNeurons {
+		for _, connID := range t.GetNeuron(nid).Inputs {
+			t.WeightGradients[connID] = 0
+		}
+		t.BiasGradients[nid] = 0
+		t.inBatch[nid] = false
+	}
+	t.batchNeurons = t.batchNeurons[:0]
+}
@@ -0,0 +1,81 @@
+package automata
+
+import (
+	"math"
+	"testing"
+)
+
+func TestApplyGradients(t *testing.T) {
+	table := &LookupTable{}
+	inputLayer := NewLayer(table, 1)
+	outputLayer := NewLayer(table, 1)
+	conn := inputLayer.Project(&outputLayer, LayerTypeAuto).List[0]
+	network := Network{
+		Input:  &inputLayer,
+		Output: &outputLayer,
+	}
+	output := outputLayer.List[0]
+	weight, bias := conn.Weight, output.Bias
+
+	table.AccumulateGradients = true
+	var wantWeightGradient, wantBiasGradient float64
+	for _, s := range []TrainSet{{[]float64{1}, []float64{0}}, {[]float64{0.5}, []float64{1}}} {
+		actual, _ := network.Activate(s.Input)
+		network.Propagate(0.5, s.Output)
+		wantWeightGradient += (s.Output[0] - actual[0]) * s.Input[0]
+		wantBiasGradient += s.Output[0] - actual[0]
+	}
+	if conn.Weight != weight || output.Bias != bias {
+		t.Fatalf("Propagate updated weights whilst accumulating gradients")
+	}
+
+	table.ApplyGradients(0.5, 2)
+	if want := weight + 0.5*wantWeightGradient/2; math.Abs(conn.Weight-want) > 1e-12 {
+		t.Errorf("weight: want %v, got %v", want, conn.Weight)
+	}
+	if want := bias + 0.5*wantBiasGradient/2; math.Abs(output.Bias-want) > 1e-12 {
+		t.Errorf("bias: want %v, got %v", want, output.Bias)
+	}
+	if table.WeightGradients[conn.ID] != 0 || table.BiasGradients[output.ID] != 0 {
+		t.Errorf("ApplyGradients did not reset the accumulated gradients")
+	}
+}
+
+func TestTrainerBatchSize(t *testing.T) {
+	for _, batchSize := range []int{2, 3, FullBatch} {
+		table := &LookupTable{}
+		inputLayer := NewLayer(table, 2)
+		outputLayer := NewLayer(table, 1)
+		inputLayer.Project(&outputLayer, LayerTypeAuto)
+		network := Network{
+			Input:  &inputLayer,
+			Output: &outputLayer,
+		}
+		trainer := Trainer{
+			Network:      &network,
+			MaxErrorRate: 0.001,
+			LearnRate:    0.5,
+			Iterations:   5000,
+			CostFunction: &MeanSquaredErrorCost{},
+			BatchSize:    batchSize,
+		}
+		sets := []TrainSet{
+			{[]float64{0, 0}, []float64{0}},
+			{[]float64{0, 1}, []float64{1}},
+			{[]float64{1, 0}, []float64{1}},
+			{[]float64{1, 1}, []float64{1}},
+		}
+		if _, err := trainer.Train(sets); err != nil {
+			t.Fatalf("BatchSize %d: trainer.Train threw error: %s", batchSize, err.Error())
+		}
+		if table.AccumulateGradients {
+			t.Errorf("BatchSize %d: LookupTable is still accumulating gradients after training", batchSize)
+		}
+		for _, set := range sets {
+			output, _ := network.Activate(set.Input)
+			if math.Floor(output[0]+0.5) != set.Output[0] {
+				t.Errorf("BatchSize %d: OR(%v): want %v, got %v", batchSize, set.Input, set.Output[0], output[0])
+			}
+		}
+	}
+}
@@ -0,0 +1,508 @@
+package automata
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"math"
+	"sort"
+)
+
+// The binary format is laid out as follows, with all fixed-size values in little-endian byte order:
+//
+//   magic (4 bytes) | version (uint16) | kind (1 byte) | network | checksum (uint32)
+//
+// The network section stores neurons, then connections, then layers. Counts and IDs are written as varints,
+// with lists of IDs delta-encoded. Weights and other parameters are written as float64s. The checksum is the
+// CRC-32 (IEEE) of everything which precedes it.
+const (
+	binaryMagic   = "ATMN"
+	binaryVersion = 2 // version 1 did not store layer activations
+)
+
+const (
+	binaryKindNetwork byte = iota
+	binaryKindHopfield
+)
+
+// maxBinaryStringLen bounds the length of strings read from binary data, to avoid huge allocations when
+// reading corrupted files.
+const maxBinaryStringLen = 1 << 12
+
+var (
+	// ErrBinaryMagic is returned when reading data which does not start with the binary network header.
+	ErrBinaryMagic = errors.New("binary: data is not an automata network")
+	// ErrBinaryTruncated is returned when the data ends before the network has been fully read.
+	ErrBinaryTruncated = errors.New("binary: unexpected end of data, network is truncated")
+	// ErrBinaryChecksum is returned when the data does not match the checksum stored with it.
+	ErrBinaryChecksum = errors.New("binary: checksum mismatch, network is corrupted")
+)
+
+// WriteBinary writes the network and every neuron and connection in its LookupTable to w in a compact binary
+// format. Use ReadNetworkBinary to read it back.
+func (n *Network) WriteBinary(w io.Writer) error {
+	return n.writeBinary(w, binaryKindNetwork)
+}
+
+// WriteBinary writes the Hopfield network to w in a compact binary format. Use ReadHopfieldBinary to read it back.
+func (h *Hopfield) WriteBinary(w io.Writer) error {
+	return h.Network.writeBinary(w, binaryKindHopfield)
+}
+
+// ReadNetworkBinary reads a network which was written with Network.WriteBinary. A new LookupTable is created to
+// hold the neurons and connections.
+func ReadNetworkBinary(r io.Reader) (*Network, error) {
+	return readBinary(r, binaryKindNetwork)
+}
+
+// ReadHopfieldBinary reads a Hopfield network which was written with Hopfield.WriteBinary. A new LookupTable is
+// created to hold the neurons and connections.
+func ReadHopfieldBinary(r io.Reader) (*Hopfield, error) {
+	network, err := readBinary(r, binaryKindHopfield)
+	if err != nil {
+		return nil, err
+	}
+	return &Hopfield{Network: *network}, nil
+}
+
+func binaryKindName(kind byte) string {
+	switch kind {
+	case binaryKindNetwork:
+		return "Network"
+	case binaryKindHopfield:
+		return "Hopfield"
+	}
+	return fmt.Sprintf("unknown kind %d", kind)
+}
+
+func (n *Network) writeBinary(w io.Writer, kind byte) error {
+	snap, err := snapshotNetwork(n)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	enc := &binaryEncoder{w: bw, crc: crc32.NewIEEE()}
+	enc.write([]byte(binaryMagic))
+	enc.uint16(binaryVersion)
+	enc.write([]byte{kind})
+	enc.network(snap)
+	if enc.err != nil {
+		return enc.err
+	}
+	var sum [4]byte
+	binary.LittleEndian.PutUint32(sum[:], enc.crc.Sum32())
+	if _, err = bw.Write(sum[:]); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func readBinary(r io.Reader, kind byte) (*Network, error) {
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	dec := &binaryDecoder{r: br, crc: crc32.NewIEEE()}
+	magic := dec.read(len(binaryMagic))
+	if dec.err == nil && string(magic) != binaryMagic {
+		return nil, ErrBinaryMagic
+	}
+	if dec.version = dec.uint16(); dec.err == nil && (dec.version < 1 || dec.version > binaryVersion) {
+		return nil, fmt.Errorf("binary: unsupported version %d, want %d", dec.version, binaryVersion)
+	}
+	if got := dec.byte(); dec.err == nil && got != kind {
+		return nil, fmt.Errorf("binary: data contains a %s, not a %s", binaryKindName(got), binaryKindName(kind))
+	}
+	snap := dec.network()
+	if dec.err != nil {
+		return nil, dec.err
+	}
+	want := dec.crc.Sum32()
+	sum := dec.read(4)
+	if dec.err != nil {
+		return nil, dec.err
+	}
+	if binary.LittleEndian.Uint32(sum) != want {
+		return nil, ErrBinaryChecksum
+	}
+	for i := range snap.Neurons {
+		ns := &snap.Neurons[i]
+		traces := ns.TraceEligibility
+		ns.TraceEligibility = nil
+		for j, cid := range ns.Inputs {
+			if cid < 0 {
+				return nil, fmt.Errorf("binary: neuron %d has negative input connection ID %d", ns.ID, cid)
+			}
+			if int(cid) >= len(ns.TraceEligibility) {
+				ns.TraceEligibility = append(ns.TraceEligibility, make([]float64, int(cid)+1-len(ns.TraceEligibility))...)
+			}
+			ns.TraceEligibility[cid] = traces[j]
+		}
+	}
+	return snap.restore(&LookupTable{})
+}
+
+// binaryEncoder writes values whilst keeping a running checksum. The first error encountered is kept in 'err'
+// and all further writes are ignored.
+type binaryEncoder struct {
+	w   io.Writer
+	crc hash.Hash32
+	err error
+	buf [binary.MaxVarintLen64]byte
+}
+
+func (e *binaryEncoder) write(b []byte) {
+	if e.err != nil {
+		return
+	}
+	if _, e.err = e.w.Write(b); e.err == nil {
+		e.crc.Write(b)
+	}
+}
+
+func (e *binaryEncoder) uint16(v uint16) {
+	binary.LittleEndian.PutUint16(e.buf[:], v)
+	e.write(e.buf[:2])
+}
+
+func (e *binaryEncoder) uvarint(v uint64) {
+	e.write(e.buf[:binary.PutUvarint(e.buf[:], v)])
+}
+
+func (e *binaryEncoder) varint(v int64) {
+	e.write(e.buf[:binary.PutVarint(e.buf[:], v)])
+}
+
+func (e *binaryEncoder) float(v float64) {
+	binary.LittleEndian.PutUint64(e.buf[:], math.Float64bits(v))
+	e.write(e.buf[:8])
+}
+
+func (e *binaryEncoder) string(s string) {
+	e.uvarint(uint64(len(s)))
+	e.write([]byte(s))
+}
+
+// ids writes a delta-encoded list of IDs. IDs are mostly allocated sequentially so the deltas are small.
+func (e *binaryEncoder) ids(ids []int64) {
+	e.uvarint(uint64(len(ids)))
+	var prev int64
+	for _, id := range ids {
+		e.varint(id - prev)
+		prev = id
+	}
+}
+
+func (e *binaryEncoder) connIDs(ids []ConnID) {
+	list := make([]int64, len(ids))
+	for i := range ids {
+		list[i] = int64(ids[i])
+	}
+	e.ids(list)
+}
+
+func (e *binaryEncoder) neuronIDs(ids []NeuronID) {
+	list := make([]int64, len(ids))
+	for i := range ids {
+		list[i] = int64(ids[i])
+	}
+	e.ids(list)
+}
+
+func (e *binaryEncoder) network(snap *networkSnapshot) {
+	e.uvarint(uint64(len(snap.Neurons)))
+	for i := range snap.Neurons {
+		e.neuron(&snap.Neurons[i])
+	}
+	e.uvarint(uint64(len(snap.Connections)))
+	for _, cs := range snap.Connections {
+		e.varint(int64(cs.ID))
+		e.varint(int64(cs.From))
+		e.varint(int64(cs.To))
+		gater := int64(-1)
+		if cs.Gater != nil {
+			gater = int64(*cs.Gater)
+		}
+		e.varint(gater)
+		e.float(cs.Weight)
+		e.float(cs.Gain)
+	}
+	e.uvarint(uint64(len(snap.Hidden)))
+	layers := append(append([]layerSnapshot{snap.Input}, snap.Hidden...), snap.Output)
+	for _, ls := range layers {
+		e.neuronIDs(ls.Neurons)
+		e.uvarint(uint64(ls.Activation))
+		e.uvarint(uint64(len(ls.ConnectedTo)))
+		for _, lcs := range ls.ConnectedTo {
+			e.uvarint(uint64(lcs.From))
+			e.uvarint(uint64(lcs.To))
+			e.uvarint(uint64(lcs.Type))
+			e.connIDs(lcs.Connections)
+		}
+	}
+}
+
+func (e *binaryEncoder) neuron(ns *neuronSnapshot) {
+	for _, v := range []float64{
+		ns.Old, ns.State, ns.Derivative, ns.Activation, ns.Bias,
+		ns.ErrorResponsibility, ns.ErrorProjected, ns.ErrorGated,
+	} {
+		e.float(v)
+	}
+	e.varint(int64(ns.Self))
+	e.string(ns.Squash)
+	e.neuronIDs(ns.Neighbours)
+	e.connIDs(ns.Inputs)
+	e.connIDs(ns.Projected)
+	e.connIDs(ns.Gated)
+
+	// Eligibility traces are only ever read for input connections, so only those are stored.
+	for _, id := range ns.Inputs {
+		var trace float64
+		if int(id) < len(ns.TraceEligibility) {
+			trace = ns.TraceEligibility[id]
+		}
+		e.float(trace)
+	}
+
+	e.uvarint(uint64(len(ns.TraceExtended)))
+	for _, nid := range sortedNeuronIDs(ns.TraceExtended) {
+		xtrace := ns.TraceExtended[nid]
+		cids := make([]ConnID, 0, len(xtrace))
+		for cid := range xtrace {
+			cids = append(cids, cid)
+		}
+		sort.Slice(cids, func(i, j int) bool { return cids[i] < cids[j] })
+		e.varint(int64(nid))
+		e.connIDs(cids)
+		for _, cid := range cids {
+			e.float(xtrace[cid])
+		}
+	}
+
+	nids := make([]NeuronID, 0, len(ns.TraceInfluences))
+	for nid := range ns.TraceInfluences {
+		nids = append(nids, nid)
+	}
+	sort.Slice(nids, func(i, j int) bool { return nids[i] < nids[j] })
+	e.uvarint(uint64(len(nids)))
+	for _, nid := range nids {
+		e.varint(int64(nid))
+		e.connIDs(ns.TraceInfluences[nid])
+	}
+}
+
+func sortedNeuronIDs(m map[NeuronID]map[ConnID]float64) []NeuronID {
+	nids := make([]NeuronID, 0, len(m))
+	for nid := range m {
+		nids = append(nids, nid)
+	}
+	sort.Slice(nids, func(i, j int) bool { return nids[i] < nids[j] })
+	return nids
+}
+
+// binaryDecoder reads values whilst keeping a running checksum. The first error encountered is kept in 'err'
+// and all further reads return zero values.
+type binaryDecoder struct {
+	r       io.ByteReader
+	crc     hash.Hash32
+	err     error
+	version uint16
+}
+
+func (d *binaryDecoder) fail(err error) {
+	if d.err != nil {
+		return
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = ErrBinaryTruncated
+	}
+	d.err = err
+}
+
+func (d *binaryDecoder) ReadByte() (byte, error) {
+	if d.err != nil {
+		return 0, d.err
+	}
+	b, err := d.r.ReadByte()
+	if err != nil {
+		d.fail(err)
+		return 0, d.err
+	}
+	d.crc.Write([]byte{b})
+	return b, nil
+}
+
+func (d *binaryDecoder) read(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i], _ = d.ReadByte()
+	}
+	return b
+}
+
+func (d *binaryDecoder) byte() byte {
+	b, _ := d.ReadByte()
+	return b
+}
+
+func (d *binaryDecoder) uint16() uint16 {
+	return binary.LittleEndian.Uint16(d.read(2))
+}
+
+func (d *binaryDecoder) uvarint() uint64 {
+	v, err := binary.ReadUvarint(d)
+	if err != nil {
+		d.fail(err)
+	}
+	return v
+}
+
+func (d *binaryDecoder) varint() int64 {
+	v, err := binary.ReadVarint(d)
+	if err != nil {
+		d.fail(err)
+	}
+	return v
+}
+
+// count reads the length of a list. Lists are read one element at a time so a corrupted count will hit the end
+// of the data rather than allocating huge amounts of memory.
+func (d *binaryDecoder) count() int {
+	v := d.uvarint()
+	if v > math.MaxInt32 {
+		d.fail(fmt.Errorf("binary: invalid list length %d", v))
+		return 0
+	}
+	return int(v)
+}
+
+func (d *binaryDecoder) float() float64 {
+	return math.Float64frombits(binary.LittleEndian.Uint64(d.read(8)))
+}
+
+func (d *binaryDecoder) string() string {
+	n := d.uvarint()
+	if n > maxBinaryStringLen {
+		d.fail(fmt.Errorf("binary: invalid string length %d", n))
+		return ""
+	}
+	return string(d.read(int(n)))
+}
+
+func (d *binaryDecoder) ids() []int64 {
+	var ids []int64
+	var prev int64
+	for i, n := 0, d.count(); i < n && d.err == nil; i++ {
+		prev += d.varint()
+		ids = append(ids, prev)
+	}
+	return ids
+}
+
+func (d *binaryDecoder) connIDs() []ConnID {
+	var ids []ConnID
+	for _, id := range d.ids() {
+		ids = append(ids, ConnID(id))
+	}
+	return ids
+}
+
+func (d *binaryDecoder) neuronIDs() []NeuronID {
+	var ids []NeuronID
+	for _, id := range d.ids() {
+		ids = append(ids, NeuronID(id))
+	}
+	return ids
+}
+
+func (d *binaryDecoder) network() *networkSnapshot {
+	var snap networkSnapshot
+	for i, n := 0, d.count(); i < n && d.err == nil; i++ {
+		snap.Neurons = append(snap.Neurons, d.neuron(NeuronID(i)))
+	}
+	for i, n := 0, d.count(); i < n && d.err == nil; i++ {
+		cs := connectionSnapshot{
+			ID:   ConnID(d.varint()),
+			From: NeuronID(d.varint()),
+			To:   NeuronID(d.varint()),
+		}
+		if gater := d.varint(); gater >= 0 {
+			g := NeuronID(gater)
+			cs.Gater = &g
+		}
+		cs.Weight = d.float()
+		cs.Gain = d.float()
+		snap.Connections = append(snap.Connections, cs)
+	}
+	numHidden := d.count()
+	var layers []layerSnapshot
+	for i := 0; i < numHidden+2 && d.err == nil; i++ {
+		ls := layerSnapshot{
+			Neurons: d.neuronIDs(),
+		}
+		if d.version >= 2 {
+			ls.Activation = LayerActivation(d.uvarint())
+		}
+		for j, n := 0, d.count(); j < n && d.err == nil; j++ {
+			ls.ConnectedTo = append(ls.ConnectedTo, layerConnectionSnapshot{
+				From:        d.count(),
+				To:          d.count(),
+				Type:        LayerType(d.uvarint()),
+				Connections: d.connIDs(),
+			})
+		}
+		layers = append(layers, ls)
+	}
+	if d.err != nil {
+		return nil
+	}
+	snap.Input = layers[0]
+	snap.Hidden = layers[1 : len(layers)-1]
+	snap.Output = layers[len(layers)-1]
+	return &snap
+}
+
+func (d *binaryDecoder) neuron(id NeuronID) neuronSnapshot {
+	ns := neuronSnapshot{
+		ID:                  id,
+		Old:                 d.float(),
+		State:               d.float(),
+		Derivative:          d.float(),
+		Activation:          d.float(),
+		Bias:                d.float(),
+		ErrorResponsibility: d.float(),
+		ErrorProjected:      d.float(),
+		ErrorGated:          d.float(),
+		Self:                ConnID(d.varint()),
+		Squash:              d.string(),
+		Neighbours:          d.neuronIDs(),
+		Inputs:              d.connIDs(),
+		Projected:           d.connIDs(),
+		Gated:               d.connIDs(),
+		TraceExtended:       make(map[NeuronID]map[ConnID]float64),
+		TraceInfluences:     make(map[NeuronID][]ConnID),
+	}
+	// Stored in input order for now: expanding these into TraceEligibility is deferred until the checksum has
+	// been verified, as it allocates based on connection IDs which may be corrupted.
+	for range ns.Inputs {
+		ns.TraceEligibility = append(ns.TraceEligibility, d.float())
+	}
+	for i, n := 0, d.count(); i < n && d.err == nil; i++ {
+		nid := NeuronID(d.varint())
+		xtrace := make(map[ConnID]float64)
+		for _, cid := range d.connIDs() {
+			xtrace[cid] = d.float()
+		}
+		ns.TraceExtended[nid] = xtrace
+	}
+	for i, n := 0, d.count(); i < n && d.err == nil; i++ {
+		nid := NeuronID(d.varint())
+		ns.TraceInfluences[nid] = d.connIDs()
+	}
+	return ns
+}
@@ -0,0 +1,87 @@
+package automata_test
+
+import (
+	"bytes"
+	"github.com/Kegsay/automata"
+	"testing"
+)
+
+func TestNetworkBinaryRoundTrip(t *testing.T) {
+	testLookupTable := &automata.LookupTable{}
+	lstm := automata.NewLSTM(testLookupTable, 2, []int{4, 3}, 2)
+	lstm.Output.Activation = automata.LayerActivationSoftmax
+	// give the memory cells some state to carry over
+	lstm.Activate([]float64{1, 0})
+	lstm.Activate([]float64{0, 1})
+
+	var buf bytes.Buffer
+	if err := lstm.WriteBinary(&buf); err != nil {
+		t.Fatalf("WriteBinary threw error: %s", err.Error())
+	}
+	loaded, err := automata.ReadNetworkBinary(&buf)
+	if err != nil {
+		t.Fatalf("ReadNetworkBinary threw error: %s", err.Error())
+	}
+	for _, input := range [][]float64{{0, 0}, {0, 1}, {1, 1}, {1, 0}} {
+		want, _ := lstm.Activate(input)
+		got, _ := loaded.Activate(input)
+		for i := range want {
+			if want[i] != got[i] {
+				t.Errorf("Activate(%v): want %v, got %v", input, want, got)
+			}
+		}
+	}
+}
+
+func TestHopfieldBinaryRoundTrip(t *testing.T) {
+	testLookupTable := &automata.LookupTable{}
+	hopfield := automata.NewHopfieldNetwork(testLookupTable, 9)
+	var buf bytes.Buffer
+	if err := hopfield.WriteBinary(&buf); err != nil {
+		t.Fatalf("WriteBinary threw error: %s", err.Error())
+	}
+	data := buf.Bytes()
+
+	if _, err := automata.ReadNetworkBinary(bytes.NewReader(data)); err == nil {
+		t.Errorf("ReadNetworkBinary: expected error reading a Hopfield network, got nil")
+	}
+	loaded, err := automata.ReadHopfieldBinary(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadHopfieldBinary threw error: %s", err.Error())
+	}
+	input := []float64{1, 0, 1, 0, 1, 0, 1, 0, 1}
+	want, _ := hopfield.Activate(input)
+	got, _ := loaded.Activate(input)
+	for i := range want {
+		if want[i] != got[i] {
+			t.Errorf("Activate(%v): want %v, got %v", input, want, got)
+		}
+	}
+}
+
+func TestNetworkBinaryErrors(t *testing.T) {
+	testLookupTable := &automata.LookupTable{}
+	perceptron, err := automata.NewPerceptronNetwork(testLookupTable, []int{2, 3, 1})
+	if err != nil {
+		t.Fatalf("Failed to create NewPerceptronNetwork: %s", err.Error())
+	}
+	var buf bytes.Buffer
+	if err = perceptron.WriteBinary(&buf); err != nil {
+		t.Fatalf("WriteBinary threw error: %s", err.Error())
+	}
+	data := buf.Bytes()
+
+	if _, err = automata.ReadNetworkBinary(bytes.NewReader(data[:len(data)/2])); err != automata.ErrBinaryTruncated {
+		t.Errorf("truncated data: want ErrBinaryTruncated, got %v", err)
+	}
+
+	corrupted := append([]byte(nil), data...)
+	corrupted[len(corrupted)-1] ^= 0xff // flip bits in the checksum
+	if _, err = automata.ReadNetworkBinary(bytes.NewReader(corrupted)); err != automata.ErrBinaryChecksum {
+		t.Errorf("corrupted data: want ErrBinaryChecksum, got %v", err)
+	}
+
+	if _, err = automata.ReadNetworkBinary(bytes.NewReader([]byte("{\"neurons\": []}"))); err != automata.ErrBinaryMagic {
+		t.Errorf("JSON data: want ErrBinaryMagic, got %v", err)
+	}
+}
@@ -0,0 +1,477 @@
+package automata
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Checkpoint configures how often Trainer.Train writes checkpoints, which can be used to resume training with
+// ResumeTrainer. Checkpoints are written at the end of an iteration to files named "checkpoint-<iteration>.json"
+// in Dir. The checkpoint with the lowest error rate is also written to "best.json", using the validation error
+// rate if there is a validation set. Checkpoints are not written by Trainer.CrossValidate.
+type Checkpoint struct {
+	// Dir is the directory to write checkpoints to. It is created if it does not exist.
+	Dir string
+	// Every writes a checkpoint every N iterations. If 0, checkpoints are only written based on Interval.
+	Every int
+	// Interval writes a checkpoint once this much time has passed since the last one. If 0, checkpoints are
+	// only written based on Every.
+	Interval time.Duration
+	// Keep is the number of most recent checkpoints to keep, not including "best.json". If 0, every checkpoint
+	// is kept.
+	Keep int
+}
+
+// checkpointFile is the contents of a checkpoint. It holds everything needed to continue training exactly where
+// it left off: the network including neuron state and eligibility traces, the optimizer state, the training
+// history and the trainer configuration.
+type checkpointFile struct {
+	// Iteration is the number of iterations which had been run when the checkpoint was written.
+	Iteration              int               `json:"iteration"`
+	Elapsed                time.Duration     `json:"elapsed"`
+	ErrorHistory           []float64         `json:"error_history"`
+	ValidationErrorHistory []float64         `json:"validation_error_history,omitempty"`
+	Best                   float64           `json:"best"`
+	Hopfield               bool              `json:"hopfield,omitempty"`
+	Network                *Network          `json:"network"`
+	WeightStates           []OptimizerState  `json:"weight_states,omitempty"`
+	BiasStates             []OptimizerState  `json:"bias_states,omitempty"`
+	Trainer                trainerCheckpoint `json:"trainer"`
+	// BestWeights and BestBiases are from the iteration with the best validation error rate, when using
+	// EarlyStopping.
+	BestWeights []float64 `json:"best_weights,omitempty"`
+	BestBiases  []float64 `json:"best_biases,omitempty"`
+	// ShuffleDraws is the number of values drawn from the source used for shuffling.
+	ShuffleDraws int64 `json:"shuffle_draws,omitempty"`
+}
+
+type trainerCheckpoint struct {
+	LearnRate         float64        `json:"learn_rate"`
+	Iterations        int            `json:"iterations"`
+	MaxErrorRate      float64        `json:"max_error_rate"`
+	BatchSize         int            `json:"batch_size"`
+	Replicas          int            `json:"replicas,omitempty"`
+	LogEvery          int            `json:"log_every"`
+	Shuffle           ShuffleMode    `json:"shuffle,omitempty"`
+	Sequential        []int          `json:"sequential,omitempty"`
+	CostFunction      *namedValue    `json:"cost_function,omitempty"`
+	Optimizer         *namedValue    `json:"optimizer,omitempty"`
+	LearnRateSchedule *namedValue    `json:"learn_rate_schedule,omitempty"`
+	EarlyStopping     *EarlyStopping `json:"early_stopping,omitempty"`
+	Checkpoint        *Checkpoint    `json:"checkpoint,omitempty"`
+}
+
+// namedValue is one of the built-in cost functions, optimizers or learning rate schedules. 'Then' is only used
+// by LinearWarmupSchedule, for the schedule it warms up to, and by WeightedCost, for the cost it weights.
+type namedValue struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value,omitempty"`
+	Then  *namedValue     `json:"then,omitempty"`
+}
+
+// ResumeTrainer loads the most recent checkpoint in dir. Calling Train on the returned Trainer continues training
+// from the iteration after the checkpoint, and the TrainResult includes the iterations run before the checkpoint.
+//
+// The network, LookupTable, optimizer state and built-in cost functions, optimizers and learning rate schedules
+// are restored. Any other implementations of Coster, Optimizer or LearnRateSchedule are not saved in checkpoints
+// and must be set on the returned Trainer, as must OnIteration and ValidationSet. If a ShuffleSource was used, a
+// source with the same seed must also be set, and it is advanced past the values used before the checkpoint.
+func ResumeTrainer(dir string) (*Trainer, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "checkpoint-*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("ResumeTrainer: no checkpoints in %s", dir)
+	}
+	return LoadCheckpoint(paths[len(paths)-1])
+}
+
+// LoadCheckpoint loads the checkpoint at the given path, such as "best.json". See ResumeTrainer.
+func LoadCheckpoint(path string) (*Trainer, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file checkpointFile
+	if err = json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if file.Network == nil || len(file.ErrorHistory) != file.Iteration {
+		return nil, fmt.Errorf("LoadCheckpoint: %s is not a valid checkpoint", path)
+	}
+	table := file.Network.LookupTable()
+	table.WeightStates = file.WeightStates
+	table.BiasStates = file.BiasStates
+
+	t := &Trainer{
+		Network:       file.Network,
+		LearnRate:     file.Trainer.LearnRate,
+		Iterations:    file.Trainer.Iterations,
+		MaxErrorRate:  file.Trainer.MaxErrorRate,
+		BatchSize:     file.Trainer.BatchSize,
+		Replicas:      file.Trainer.Replicas,
+		LogEvery:      file.Trainer.LogEvery,
+		Shuffle:       file.Trainer.Shuffle,
+		Sequential:    file.Trainer.Sequential,
+		EarlyStopping: file.Trainer.EarlyStopping,
+		Checkpoint:    file.Trainer.Checkpoint,
+		resume:        &file,
+	}
+	if file.Hopfield {
+		t.Network = &Hopfield{Network: *file.Network}
+	}
+
+	var ok bool
+	if v, err := file.Trainer.CostFunction.decode(); err != nil {
+		return nil, err
+	} else if t.CostFunction, ok = v.(Coster); v != nil && !ok {
+		return nil, fmt.Errorf("LoadCheckpoint: %s is not a cost function", file.Trainer.CostFunction.Type)
+	}
+	if v, err := file.Trainer.Optimizer.decode(); err != nil {
+		return nil, err
+	} else if t.Optimizer, ok = v.(Optimizer); v != nil && !ok {
+		return nil, fmt.Errorf("LoadCheckpoint: %s is not an optimizer", file.Trainer.Optimizer.Type)
+	}
+	if v, err := file.Trainer.LearnRateSchedule.decode(); err != nil {
+		return nil, err
+	} else if t.LearnRateSchedule, ok = v.(LearnRateSchedule); v != nil && !ok {
+		return nil, fmt.Errorf("LoadCheckpoint: %s is not a learning rate schedule", file.Trainer.LearnRateSchedule.Type)
+	}
+	return t, nil
+}
+
+// resumeResult fills in the result with the history from the checkpoint being resumed, and replays the learning
+// rate schedule and early stopping so any state they keep matches the original training run. Returns the next
+// iteration to run and the error rate of the last iteration.
+func (t *Trainer) resumeResult(result *TrainResult, stopper *earlyStopper) (int, float64) {
+	file := t.resume
+	errRate := math.Inf(1)
+	for i, e := range file.ErrorHistory {
+		t.learnRate(i, errRate)
+		errRate = e
+	}
+	if stopper != nil {
+		for _, e := range file.ValidationErrorHistory {
+			stopper.update(e)
+		}
+		stopper.params = parameters{weights: file.BestWeights, biases: file.BestBiases}
+	}
+	result.Iterations = file.Iteration
+	result.Elapsed = file.Elapsed
+	result.ErrorHistory = append(result.ErrorHistory, file.ErrorHistory...)
+	result.ValidationErrorHistory = append(result.ValidationErrorHistory, file.ValidationErrorHistory...)
+	if len(result.ErrorHistory) > 0 {
+		result.Error = errRate
+	}
+	return file.Iteration, errRate
+}
+
+// checkpointer writes the checkpoints for a single training run.
+type checkpointer struct {
+	*Checkpoint
+	last time.Time
+	best float64
+}
+
+// due returns true if a checkpoint should be written after the given number of iterations.
+func (c *checkpointer) due(iteration int) bool {
+	return (c.Every > 0 && iteration%c.Every == 0) || (c.Interval > 0 && time.Since(c.last) >= c.Interval)
+}
+
+// write a checkpoint of the trainer after the given result. 'score' is the error rate used to decide whether this
+// is the best checkpoint so far.
+func (c *checkpointer) write(t *Trainer, result *TrainResult, elapsed time.Duration, score float64, stopper *earlyStopper, shuffle *shuffler) error {
+	c.last = time.Now()
+	isBest := score < c.best
+	if isBest {
+		c.best = score
+	}
+	file, err := t.checkpointFile(result, elapsed, c.best)
+	if err != nil {
+		return err
+	}
+	if stopper != nil {
+		file.BestWeights = stopper.params.weights
+		file.BestBiases = stopper.params.biases
+	}
+	if shuffle != nil {
+		file.ShuffleDraws = shuffle.source.draws
+	}
+	data, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(c.Dir, 0755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("checkpoint-%09d.json", result.Iterations)
+	if err = writeFileAtomic(filepath.Join(c.Dir, name), data); err != nil {
+		return err
+	}
+	if isBest {
+		if err = writeFileAtomic(filepath.Join(c.Dir, "best.json"), data); err != nil {
+			return err
+		}
+	}
+	if c.Keep < 1 {
+		return nil
+	}
+	paths, err := filepath.Glob(filepath.Join(c.Dir, "checkpoint-*.json"))
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(paths)-c.Keep; i++ {
+		if err = os.Remove(paths[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkpointFile creates a checkpoint of the trainer after the given result.
+func (t *Trainer) checkpointFile(result *TrainResult, elapsed time.Duration, best float64) (*checkpointFile, error) {
+	file := &checkpointFile{
+		Iteration:              result.Iterations,
+		Elapsed:                elapsed,
+		ErrorHistory:           result.ErrorHistory,
+		ValidationErrorHistory: result.ValidationErrorHistory,
+		Best:                   best,
+	}
+	switch network := t.Network.(type) {
+	case *Network:
+		file.Network = network
+	case *Hopfield:
+		file.Network = &network.Network
+		file.Hopfield = true
+	default:
+		return nil, fmt.Errorf("Checkpoint: cannot checkpoint network of type %T", t.Network)
+	}
+	table := file.Network.LookupTable()
+	file.WeightStates = table.WeightStates
+	file.BiasStates = table.BiasStates
+
+	var err error
+	file.Trainer = trainerCheckpoint{
+		LearnRate:     t.LearnRate,
+		Iterations:    t.Iterations,
+		MaxErrorRate:  t.MaxErrorRate,
+		BatchSize:     t.BatchSize,
+		Replicas:      t.Replicas,
+		LogEvery:      t.LogEvery,
+		Shuffle:       t.Shuffle,
+		Sequential:    t.Sequential,
+		EarlyStopping: t.EarlyStopping,
+		Checkpoint:    t.Checkpoint,
+	}
+	if file.Trainer.CostFunction, err = encodeNamedValue(t.CostFunction); err != nil {
+		return nil, err
+	}
+	if file.Trainer.Optimizer, err = encodeNamedValue(table.Optimizer); err != nil {
+		return nil, err
+	}
+	if file.Trainer.LearnRateSchedule, err = encodeNamedValue(t.LearnRateSchedule); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// encodeNamedValue encodes a built-in cost function, optimizer or learning rate schedule. Returns nil if v is nil
+// or is not built-in.
+func encodeNamedValue(v interface{}) (*namedValue, error) {
+	name := namedValueType(v)
+	if name == "" {
+		return nil, nil
+	}
+	var err error
+	nv := &namedValue{Type: name}
+	if s, ok := v.(*LinearWarmupSchedule); ok {
+		if nv.Value, err = json.Marshal(struct{ Steps int }{s.Steps}); err != nil {
+			return nil, err
+		}
+		nv.Then, err = encodeNamedValue(s.Then)
+		return nv, err
+	}
+	if c, ok := v.(*WeightedCost); ok {
+		if nv.Then, err = encodeNamedValue(c.Coster); err != nil || nv.Then == nil {
+			return nil, err
+		}
+		nv.Value, err = json.Marshal(struct{ Weights []float64 }{c.Weights})
+		return nv, err
+	}
+	nv.Value, err = json.Marshal(v)
+	return nv, err
+}
+
+// decode the value. Returns nil if nv is nil.
+func (nv *namedValue) decode() (interface{}, error) {
+	if nv == nil {
+		return nil, nil
+	}
+	v := newNamedValue(nv.Type)
+	if v == nil {
+		return nil, fmt.Errorf("LoadCheckpoint: unknown type %s", nv.Type)
+	}
+	if err := json.Unmarshal(nv.Value, v); err != nil {
+		return nil, err
+	}
+	if s, ok := v.(*LinearWarmupSchedule); ok && nv.Then != nil {
+		then, err := nv.Then.decode()
+		if err != nil {
+			return nil, err
+		}
+		if s.Then, ok = then.(LearnRateSchedule); !ok {
+			return nil, fmt.Errorf("LoadCheckpoint: %s is not a learning rate schedule", nv.Then.Type)
+		}
+	}
+	if c, ok := v.(*WeightedCost); ok {
+		cost, err := nv.Then.decode()
+		if err != nil {
+			return nil, err
+		}
+		if c.Coster, ok = cost.(Coster); !ok {
+			return nil, fmt.Errorf("LoadCheckpoint: weighted cost function is not a cost function")
+		}
+	}
+	return v, nil
+}
+
+// namedValueType returns the name of a built-in cost function, optimizer or learning rate schedule, or "" if
+// it is not built-in.
+func namedValueType(v interface{}) string {
+	switch v.(type) {
+	case *MeanSquaredErrorCost:
+		return "mse"
+	case *CrossEntropyCost:
+		return "cross_entropy"
+	case *CategoricalCrossEntropyCost:
+		return "categorical_cross_entropy"
+	case *BinaryCost:
+		return "binary"
+	case *MeanAbsoluteErrorCost:
+		return "mae"
+	case *HuberCost:
+		return "huber"
+	case *LogCoshCost:
+		return "log_cosh"
+	case *HingeCost:
+		return "hinge"
+	case *KLDivergenceCost:
+		return "kl_divergence"
+	case *MeanAbsolutePercentageErrorCost:
+		return "mape"
+	case *CosineDistanceCost:
+		return "cosine_distance"
+	case *WeightedCost:
+		return "weighted"
+	case *SGDOptimizer:
+		return "sgd"
+	case *MomentumOptimizer:
+		return "momentum"
+	case *NesterovOptimizer:
+		return "nesterov"
+	case *AdaGradOptimizer:
+		return "adagrad"
+	case *RMSPropOptimizer:
+		return "rmsprop"
+	case *AdamOptimizer:
+		return "adam"
+	case *ConstantSchedule:
+		return "constant"
+	case *StepDecaySchedule:
+		return "step_decay"
+	case *ExponentialDecaySchedule:
+		return "exponential_decay"
+	case *InverseTimeDecaySchedule:
+		return "inverse_time_decay"
+	case *CosineAnnealingSchedule:
+		return "cosine_annealing"
+	case *LinearWarmupSchedule:
+		return "linear_warmup"
+	case *ReduceOnPlateauSchedule:
+		return "reduce_on_plateau"
+	}
+	return ""
+}
+
+// newNamedValue returns a new built-in cost function, optimizer or learning rate schedule with the given name, or
+// nil if there isn't one.
+func newNamedValue(name string) interface{} {
+	switch name {
+	case "mse":
+		return &MeanSquaredErrorCost{}
+	case "cross_entropy":
+		return &CrossEntropyCost{}
+	case "categorical_cross_entropy":
+		return &CategoricalCrossEntropyCost{}
+	case "binary":
+		return &BinaryCost{}
+	case "mae":
+		return &MeanAbsoluteErrorCost{}
+	case "huber":
+		return &HuberCost{}
+	case "log_cosh":
+		return &LogCoshCost{}
+	case "hinge":
+		return &HingeCost{}
+	case "kl_divergence":
+		return &KLDivergenceCost{}
+	case "mape":
+		return &MeanAbsolutePercentageErrorCost{}
+	case "cosine_distance":
+		return &CosineDistanceCost{}
+	case "weighted":
+		return &WeightedCost{}
+	case "sgd":
+		return &SGDOptimizer{}
+	case "momentum":
+		return &MomentumOptimizer{}
+	case "nesterov":
+		return &NesterovOptimizer{}
+	case "adagrad":
+		return &AdaGradOptimizer{}
+	case "rmsprop":
+		return &RMSPropOptimizer{}
+	case "adam":
+		return &AdamOptimizer{}
+	case "constant":
+		return &ConstantSchedule{}
+	case "step_decay":
+		return &StepDecaySchedule{}
+	case "exponential_decay":
+		return &ExponentialDecaySchedule{}
+	case "inverse_time_decay":
+		return &InverseTimeDecaySchedule{}
+	case "cosine_annealing":
+		return &CosineAnnealingSchedule{}
+	case "linear_warmup":
+		return &LinearWarmupSchedule{}
+	case "reduce_on_plateau":
+		return &ReduceOnPlateauSchedule{}
+	}
+	return nil
+}
+
+// writeFileAtomic writes data to a temporary file in the same directory and then renames it, so a checkpoint is
+// never left half written if the process is killed.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".checkpoint-")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
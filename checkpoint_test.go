@@ -0,0 +1,108 @@
+package automata
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCheckpointResume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "automata-checkpoint")
+	if err != nil {
+		t.Fatalf("TempDir threw error: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	network, sets := orNetwork()
+	// copy the network so both runs start from the same weights
+	data, err := json.Marshal(network)
+	if err != nil {
+		t.Fatalf("MarshalJSON threw error: %s", err.Error())
+	}
+	var interrupted Network
+	if err = json.Unmarshal(data, &interrupted); err != nil {
+		t.Fatalf("UnmarshalJSON threw error: %s", err.Error())
+	}
+	newTrainer := func(n *Network, dir string) *Trainer {
+		return &Trainer{
+			Network:           n,
+			LearnRate:         0.1,
+			Iterations:        20,
+			CostFunction:      &MeanSquaredErrorCost{},
+			Optimizer:         &AdamOptimizer{},
+			Shuffle:           ShuffleEpoch,
+			LearnRateSchedule: &LinearWarmupSchedule{Steps: 3, Then: &ReduceOnPlateauSchedule{Factor: 0.5}},
+			Checkpoint:        &Checkpoint{Dir: dir, Every: 5, Keep: 2},
+		}
+	}
+
+	want, err := newTrainer(network, filepath.Join(dir, "uninterrupted")).Train(sets)
+	if err != nil {
+		t.Fatalf("trainer.Train threw error: %s", err.Error())
+	}
+	paths, _ := filepath.Glob(filepath.Join(dir, "uninterrupted", "*.json"))
+	wantPaths := []string{"best.json", "checkpoint-000000015.json", "checkpoint-000000020.json"}
+	if len(paths) != len(wantPaths) {
+		t.Fatalf("want checkpoints %v, got %v", wantPaths, paths)
+	}
+	for i := range paths {
+		if filepath.Base(paths[i]) != wantPaths[i] {
+			t.Errorf("want checkpoint %s, got %s", wantPaths[i], paths[i])
+		}
+	}
+
+	// stop part way between checkpoints, then resume from the last one
+	trainer := newTrainer(&interrupted, filepath.Join(dir, "interrupted"))
+	trainer.OnIteration = func(iter int, errRate float64) bool {
+		return iter < 12
+	}
+	if _, err = trainer.Train(sets); err != nil {
+		t.Fatalf("trainer.Train threw error: %s", err.Error())
+	}
+	trainer, err = ResumeTrainer(filepath.Join(dir, "interrupted"))
+	if err != nil {
+		t.Fatalf("ResumeTrainer threw error: %s", err.Error())
+	}
+	if len(trainer.resume.ErrorHistory) != 10 {
+		t.Fatalf("want to resume from iteration 10, got %d", len(trainer.resume.ErrorHistory))
+	}
+	got, err := trainer.Train(sets)
+	if err != nil {
+		t.Fatalf("trainer.Train threw error: %s", err.Error())
+	}
+	if got.Iterations != want.Iterations || !reflect.DeepEqual(got.ErrorHistory, want.ErrorHistory) {
+		t.Errorf("resumed training differs:\nwant %v\ngot  %v", want.ErrorHistory, got.ErrorHistory)
+	}
+	wantParams := network.LookupTable().saveParameters()
+	gotParams := networkTable(trainer.Network).saveParameters()
+	if !reflect.DeepEqual(gotParams, wantParams) {
+		t.Errorf("resumed parameters differ: want %v, got %v", wantParams, gotParams)
+	}
+
+	if _, err = ResumeTrainer(filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("ResumeTrainer: expected error with no checkpoints, got nil")
+	}
+}
+
+func TestNamedValueWeightedCost(t *testing.T) {
+	cost := &WeightedCost{Coster: &HuberCost{Delta: 0.5}, Weights: []float64{1, 2}}
+	nv, err := encodeNamedValue(cost)
+	if err != nil {
+		t.Fatalf("encodeNamedValue threw error: %s", err.Error())
+	}
+	got, err := nv.decode()
+	if err != nil {
+		t.Fatalf("decode threw error: %s", err.Error())
+	}
+	if !reflect.DeepEqual(got, cost) {
+		t.Errorf("want %v, got %v", cost, got)
+	}
+
+	// a weighted custom cost function cannot be saved, just like the custom cost function itself
+	if nv, _ = encodeNamedValue(&WeightedCost{Coster: &quarticCost{}}); nv != nil {
+		t.Errorf("want custom weighted cost to be skipped, got %v", nv)
+	}
+}
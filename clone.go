@@ -0,0 +1,206 @@
+package automata
+
+import "sort"
+
+// cloneIDs maps between the IDs of neurons and connections in a network and in its clone.
+type cloneIDs struct {
+	neurons []NeuronID // the original ID of each neuron, indexed by its ID in the clone
+	conns   []ConnID   // the ID in the clone of each original connection, indexed by its original ID, or -1
+}
+
+// Clone returns a copy of the network with a new LookupTable holding fresh copies of its neurons and connections.
+// Training or activating the clone does not affect the original, so it can be used to fork a model or to activate
+// it concurrently.
+//
+// Neurons and connections are given new IDs in the same order as their original IDs, and every reference between
+// them is remapped. Only neurons in the network's layers, and connections between them, are copied: connections
+// to or from neurons outside the network are dropped, and connections gated by neurons outside the network keep
+// their current gain but are no longer gated. The LookupTable settings are copied apart from Rand, which is not
+// safe to share, and any accumulated gradients. Squashers are shared, as they are not modified by the network.
+func (n *Network) Clone() *Network {
+	clone, _ := n.clone()
+	return clone
+}
+
+// Clone returns a copy of the Hopfield network, in the same way as Network.Clone.
+func (h *Hopfield) Clone() *Hopfield {
+	return &Hopfield{Network: *h.Network.Clone()}
+}
+
+// clone the network, returning the clone and the mapping between their IDs.
+func (n *Network) clone() (*Network, cloneIDs) {
+	original := n.LookupTable()
+	table := &LookupTable{
+		Initializer:  original.Initializer,
+		Optimizer:    original.Optimizer,
+		CostFunction: original.CostFunction,
+		Workers:      original.Workers,
+	}
+
+	// Assign new IDs in the order of the original IDs, so anything ordered by ID stays in the same order.
+	layers := n.layers()
+	var oldNeurons []*Neuron
+	for _, layer := range layers {
+		oldNeurons = append(oldNeurons, layer.List...)
+	}
+	sort.Slice(oldNeurons, func(i, j int) bool { return oldNeurons[i].ID < oldNeurons[j].ID })
+	neurons := make(map[*Neuron]*Neuron, len(oldNeurons))
+	ids := cloneIDs{conns: make([]ConnID, len(original.Connections))}
+	for _, old := range oldNeurons {
+		if _, ok := neurons[old]; ok {
+			continue // in more than one layer
+		}
+		neuron := &Neuron{
+			Old:                 old.Old,
+			State:               old.State,
+			Derivative:          old.Derivative,
+			Activation:          old.Activation,
+			Squash:              old.Squash,
+			Bias:                old.Bias,
+			ErrorResponsibility: old.ErrorResponsibility,
+			ErrorProjected:      old.ErrorProjected,
+			ErrorGated:          old.ErrorGated,
+			TraceExtended:       make(map[NeuronID]map[ConnID]float64),
+			TraceInfluences:     make(map[NeuronID][]ConnID),
+			LookupTable:         table,
+		}
+		neuron.ID = table.SetNeuron(neuron)
+		neurons[old] = neuron
+		ids.neurons = append(ids.neurons, old.ID)
+	}
+	for i, old := range original.Connections {
+		ids.conns[i] = -1
+		if old == nil || neurons[old.From] == nil || neurons[old.To] == nil {
+			continue
+		}
+		conn := &Connection{
+			From:   neurons[old.From],
+			To:     neurons[old.To],
+			Gater:  neurons[old.Gater], // nil if not gated or gated from outside the network
+			Weight: old.Weight,
+			Gain:   old.Gain,
+		}
+		conn.ID = table.SetConnection(conn)
+		ids.conns[i] = conn.ID
+	}
+
+	neuronID := func(id NeuronID) (NeuronID, bool) {
+		if neuron := neurons[original.GetNeuron(id)]; neuron != nil {
+			return neuron.ID, true
+		}
+		return 0, false
+	}
+	connID := func(id ConnID) (ConnID, bool) {
+		if id < 0 || int(id) >= len(ids.conns) || ids.conns[id] < 0 {
+			return 0, false
+		}
+		return ids.conns[id], true
+	}
+	connIDs := func(old []ConnID) []ConnID {
+		var remapped []ConnID
+		for _, id := range old {
+			if id, ok := connID(id); ok {
+				remapped = append(remapped, id)
+			}
+		}
+		return remapped
+	}
+	for _, old := range oldNeurons {
+		neuron := neurons[old]
+		if neuron.Self != nil {
+			continue // in more than one layer, so already done
+		}
+		neuron.Self = table.GetConnection(ids.conns[old.Self.ID])
+		for _, id := range old.Neighbours {
+			if id, ok := neuronID(id); ok {
+				neuron.Neighbours = append(neuron.Neighbours, id)
+			}
+		}
+		neuron.Inputs = connIDs(old.Inputs)
+		neuron.Projected = connIDs(old.Projected)
+		neuron.Gated = connIDs(old.Gated)
+		for id, trace := range old.TraceEligibility {
+			if id, ok := connID(ConnID(id)); ok {
+				neuron.setTraceEligibility(id, trace)
+			}
+		}
+		for nid, xtrace := range old.TraceExtended {
+			nid, ok := neuronID(nid)
+			if !ok {
+				continue
+			}
+			remapped := make(map[ConnID]float64, len(xtrace))
+			for id, trace := range xtrace {
+				if id, ok := connID(id); ok {
+					remapped[id] = trace
+				}
+			}
+			neuron.TraceExtended[nid] = remapped
+		}
+		for nid, influences := range old.TraceInfluences {
+			if nid, ok := neuronID(nid); ok {
+				neuron.TraceInfluences[nid] = connIDs(influences)
+			}
+		}
+	}
+
+	if original.WeightStates != nil {
+		table.WeightStates = make([]OptimizerState, len(table.Connections))
+		for oldID, id := range ids.conns {
+			if id >= 0 && oldID < len(original.WeightStates) {
+				table.WeightStates[id] = original.WeightStates[oldID]
+			}
+		}
+	}
+	if original.BiasStates != nil {
+		table.BiasStates = make([]OptimizerState, len(table.Neurons))
+		for id, oldID := range ids.neurons {
+			if int(oldID) < len(original.BiasStates) {
+				table.BiasStates[id] = original.BiasStates[oldID]
+			}
+		}
+	}
+
+	clones := make([]Layer, len(layers))
+	for i, layer := range layers {
+		clones[i] = Layer{LookupTable: table, Activation: layer.Activation}
+		for _, neuron := range layer.List {
+			clones[i].List = append(clones[i].List, neurons[neuron])
+		}
+	}
+	layerIndex := func(l *Layer) int {
+		for i, candidate := range layers {
+			if candidate == l || sameNeurons(candidate, l) {
+				return i
+			}
+		}
+		return -1
+	}
+	for i, layer := range layers {
+		for _, lc := range layer.ConnectedTo {
+			from, to := layerIndex(lc.From), layerIndex(lc.To)
+			if from == -1 || to == -1 {
+				continue // connects to a layer outside of this network
+			}
+			clc := LayerConnection{
+				From:        &clones[from],
+				To:          &clones[to],
+				Type:        lc.Type,
+				Connections: make(map[ConnID]*Connection),
+			}
+			for _, conn := range lc.List {
+				if id, ok := connID(conn.ID); ok {
+					clc.Connections[id] = table.GetConnection(id)
+					clc.List = append(clc.List, table.GetConnection(id))
+				}
+			}
+			clones[i].ConnectedTo = append(clones[i].ConnectedTo, clc)
+		}
+	}
+
+	return &Network{
+		Input:  &clones[0],
+		Hidden: clones[1 : len(clones)-1],
+		Output: &clones[len(clones)-1],
+	}, ids
+}
@@ -0,0 +1,89 @@
+package automata_test
+
+import (
+	"github.com/Kegsay/automata"
+	"math/rand"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	table := &automata.LookupTable{Rand: rand.New(rand.NewSource(1))}
+	// a neuron with a live self-connection on the same table, so the LSTM's IDs do not start at 0 and any use
+	// of connection 0 by the LSTM would change its output
+	outside := automata.NewLayer(table, 1)
+	outside.Project(&outside, automata.LayerTypeAuto)
+	outside.Activate([]float64{1})
+	lstm := automata.NewLSTM(table, 2, []int{3}, 1)
+	lstm.Activate([]float64{1, 1}) // start from a non-zero state
+
+	clone := lstm.Clone()
+	numNeurons := len(lstm.Input.List) + len(lstm.Output.List)
+	for _, layer := range lstm.Hidden {
+		numNeurons += len(layer.List)
+	}
+	if got := len(clone.LookupTable().Neurons); got != numNeurons {
+		t.Errorf("want the clone's table to hold %d neurons, got %d", numNeurons, got)
+	}
+	if clone.LookupTable() == table {
+		t.Fatalf("want the clone to have its own table")
+	}
+
+	inputs := [][]float64{{0, 0}, {0, 1}, {1, 1}, {1, 0}, {0.5, -0.5}}
+	for _, input := range inputs {
+		want, _ := lstm.Activate(input)
+		got, err := clone.Activate(input)
+		if err != nil {
+			t.Fatalf("Activate threw error: %s", err.Error())
+		}
+		if got[0] != want[0] {
+			t.Errorf("input %v: want output %v, got %v", input, want, got)
+		}
+		if err = lstm.Propagate(0.1, []float64{input[0]}); err != nil {
+			t.Fatalf("Propagate threw error: %s", err.Error())
+		}
+		if err = clone.Propagate(0.1, []float64{input[0]}); err != nil {
+			t.Fatalf("Propagate threw error: %s", err.Error())
+		}
+		for i, layer := range lstm.Hidden {
+			for j, neuron := range layer.List {
+				if got := clone.Hidden[i].List[j].ErrorResponsibility; got != neuron.ErrorResponsibility {
+					t.Errorf("input %v: hidden layer %d neuron %d: want error responsibility %v, got %v", input, i, j, neuron.ErrorResponsibility, got)
+				}
+			}
+		}
+	}
+
+	// training the clone must not change the original
+	want, _ := lstm.Clone().Activate([]float64{1, 0})
+	trainer := automata.Trainer{
+		Network:      clone,
+		LearnRate:    0.5,
+		Iterations:   10,
+		CostFunction: &automata.MeanSquaredErrorCost{},
+	}
+	if _, err := trainer.Train([]automata.TrainSet{{[]float64{1, 0}, []float64{1}}}); err != nil {
+		t.Fatalf("trainer.Train threw error: %s", err.Error())
+	}
+	got, _ := lstm.Activate([]float64{1, 0})
+	if got[0] != want[0] {
+		t.Errorf("want the original to be unchanged by training the clone: want output %v, got %v", want, got)
+	}
+}
+
+func TestCloneHopfield(t *testing.T) {
+	hopfield := automata.NewHopfieldNetwork(&automata.LookupTable{}, 4)
+	clone := hopfield.Clone()
+	for _, pattern := range [][]float64{{0, 1, 0, 1}, {1, 1, 0, 0}} {
+		want, _ := hopfield.Activate(pattern)
+		got, err := clone.Activate(pattern)
+		if err != nil {
+			t.Fatalf("Activate threw error: %s", err.Error())
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("pattern %v: want output %v, got %v", pattern, want, got)
+				break
+			}
+		}
+	}
+}
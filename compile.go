@@ -0,0 +1,153 @@
+package automata
+
+import (
+	"fmt"
+	"math"
+)
+
+// CompiledNetwork performs the same computation as Activate on the Network it was compiled from, but stores the
+// network as flat arrays indexed by position rather than as a graph of neurons and connections, so it can be
+// activated without allocating. Use Network.Compile to create one.
+//
+// A CompiledNetwork has its own copy of the weights, biases and the state of every neuron, starting from the
+// network at the time it was compiled. It will not reflect any training done afterwards, and activating it does
+// not change the network. Recurrent networks like those made by NewLSTM produce the same sequence of outputs as
+// calling Activate on the network. A CompiledNetwork is not safe to use concurrently.
+type CompiledNetwork struct {
+	numInputs  int
+	numOutputs int
+	state      []float64 // indexed by neuron, in activation order
+	activation []float64 // indexed by neuron, in activation order
+	gain       []float64 // indexed by connection
+	neurons    []compiledNeuron
+	inputs     []compiledInput // the inputs to every neuron, in order
+	gated      []int           // the gain index of the connections gated by every neuron, in order
+}
+
+// compiledNeuron is a non-input neuron in a CompiledNetwork.
+type compiledNeuron struct {
+	bias                 float64
+	selfWeight           float64
+	selfGain             int
+	squash               Squasher
+	inputStart, inputEnd int // the range of inputs into this neuron
+	gatedStart, gatedEnd int // the range of gated connections
+	softmaxStart         int // if this is the last neuron in a softmax layer, the index of the first, else -1
+}
+
+// compiledInput is a connection into a neuron in a CompiledNetwork.
+type compiledInput struct {
+	from   int
+	weight float64
+	gain   int
+}
+
+// Compile the network into a CompiledNetwork, which is faster to activate. Returns an error if a neuron has an
+// input from a neuron which is not in the network.
+func (n *Network) Compile() (*CompiledNetwork, error) {
+	table := n.LookupTable()
+	c := &CompiledNetwork{numInputs: len(n.Input.List), numOutputs: len(n.Output.List)}
+
+	// Assign each neuron an index in activation order.
+	indexes := make(map[NeuronID]int)
+	layers := n.layers()
+	for _, layer := range layers {
+		for _, neuron := range layer.List {
+			indexes[neuron.ID] = len(c.state)
+			c.state = append(c.state, neuron.State)
+			c.activation = append(c.activation, neuron.Activation)
+		}
+	}
+	// Every connection gets a gain so gated and ungated connections are treated alike.
+	gainIndexes := make(map[ConnID]int)
+	gain := func(conn *Connection) int {
+		i, ok := gainIndexes[conn.ID]
+		if !ok {
+			i = len(c.gain)
+			gainIndexes[conn.ID] = i
+			c.gain = append(c.gain, conn.Gain)
+		}
+		return i
+	}
+
+	for _, layer := range layers[1:] {
+		start := len(c.neurons) + c.numInputs
+		for _, neuron := range layer.List {
+			cn := compiledNeuron{
+				bias:         neuron.Bias,
+				selfWeight:   neuron.Self.Weight,
+				selfGain:     gain(neuron.Self),
+				squash:       neuron.Squash,
+				inputStart:   len(c.inputs),
+				gatedStart:   len(c.gated),
+				softmaxStart: -1,
+			}
+			for _, connID := range neuron.Inputs {
+				conn := table.GetConnection(connID)
+				from, ok := indexes[conn.From.ID]
+				if !ok {
+					return nil, fmt.Errorf("Compile: neuron %d has an input from neuron %d which is not in the network", neuron.ID, conn.From.ID)
+				}
+				c.inputs = append(c.inputs, compiledInput{from: from, weight: conn.Weight, gain: gain(conn)})
+			}
+			for _, connID := range neuron.Gated {
+				c.gated = append(c.gated, gain(table.GetConnection(connID)))
+			}
+			cn.inputEnd, cn.gatedEnd = len(c.inputs), len(c.gated)
+			c.neurons = append(c.neurons, cn)
+		}
+		if layer.Activation == LayerActivationSoftmax && len(layer.List) > 0 {
+			c.neurons[len(c.neurons)-1].softmaxStart = start
+		}
+	}
+	return c, nil
+}
+
+// Activate the compiled network with the given input. The returned output is only valid until the next call to
+// Activate, so copy it to keep it.
+func (c *CompiledNetwork) Activate(input []float64) ([]float64, error) {
+	if len(input) != c.numInputs {
+		return nil, fmt.Errorf("input and layer size mismatch: cannot activate")
+	}
+	copy(c.activation, input)
+	for j := range c.neurons {
+		neuron := &c.neurons[j]
+		i := c.numInputs + j
+		// Eq. 15
+		state := c.gain[neuron.selfGain]*neuron.selfWeight*c.state[i] + neuron.bias
+		for _, in := range c.inputs[neuron.inputStart:neuron.inputEnd] {
+			state += c.activation[in.from] * in.weight * c.gain[in.gain]
+		}
+		c.state[i] = state
+		// Eq. 16
+		c.activation[i] = neuron.squash.Squash(state, false)
+		for _, g := range c.gated[neuron.gatedStart:neuron.gatedEnd] {
+			c.gain[g] = c.activation[i]
+		}
+		if neuron.softmaxStart >= 0 {
+			c.activateSoftmax(neuron.softmaxStart, i+1)
+		}
+	}
+	return c.activation[len(c.activation)-c.numOutputs:], nil
+}
+
+// activateSoftmax replaces the activations of the neurons from start to end with the softmax of their states, in
+// the same way as Layer.Activate.
+func (c *CompiledNetwork) activateSoftmax(start, end int) {
+	max := math.Inf(-1)
+	for _, s := range c.state[start:end] {
+		max = math.Max(max, s)
+	}
+	var sum float64
+	for i := start; i < end; i++ {
+		c.activation[i] = math.Exp(c.state[i] - max)
+		sum += c.activation[i]
+	}
+	for i := start; i < end; i++ {
+		c.activation[i] /= sum
+		neuron := &c.neurons[i-c.numInputs]
+		for _, g := range c.gated[neuron.gatedStart:neuron.gatedEnd] {
+			c.gain[g] = c.activation[i]
+		}
+	}
+}
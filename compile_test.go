@@ -0,0 +1,51 @@
+package automata_test
+
+import (
+	"github.com/Kegsay/automata"
+	"math/rand"
+	"testing"
+)
+
+func TestCompile(t *testing.T) {
+	table := &automata.LookupTable{Rand: rand.New(rand.NewSource(1))}
+	lstm := automata.NewLSTM(table, 2, []int{3, 2}, 3)
+	squashers := []automata.Squasher{
+		&automata.SquashTanh{}, &automata.SquashRelu{}, &automata.SquashElu{}, &automata.SquashSwish{},
+	}
+	i := 0
+	for _, layer := range lstm.Hidden {
+		for _, neuron := range layer.List {
+			neuron.Squash = squashers[i%len(squashers)]
+			i++
+		}
+	}
+	lstm.Output.Activation = automata.LayerActivationSoftmax
+	lstm.Activate([]float64{1, 1}) // start from a non-zero state
+
+	compiled, err := lstm.Compile()
+	if err != nil {
+		t.Fatalf("Compile threw error: %s", err.Error())
+	}
+	inputs := [][]float64{{0, 0}, {0, 1}, {1, 1}, {1, 0}, {0.5, -0.5}, {0, 0}}
+	for _, input := range inputs {
+		want, _ := lstm.Activate(input)
+		got, err := compiled.Activate(input)
+		if err != nil {
+			t.Fatalf("Activate threw error: %s", err.Error())
+		}
+		for j := range want {
+			if got[j] != want[j] {
+				t.Errorf("input %v: want output %v, got %v", input, want, got)
+				break
+			}
+		}
+	}
+
+	if _, err = compiled.Activate([]float64{1}); err == nil {
+		t.Errorf("Activate: expected error with the wrong number of inputs, got nil")
+	}
+	input := []float64{0.3, 0.7}
+	if allocs := testing.AllocsPerRun(100, func() { compiled.Activate(input) }); allocs != 0 {
+		t.Errorf("want Activate to not allocate, got %v allocations", allocs)
+	}
+}
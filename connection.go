@@ -1,7 +1,6 @@
 package automata
 
 import "fmt"
-import "math/rand"
 
 type LayerType int
 
@@ -26,7 +25,7 @@ type Connection struct {
 
 func NewConnection(from, to *Neuron, weight *float64) *Connection {
 	if weight == nil {
-		w := (rand.Float64() * 0.2) - 0.1 // random weight between -0.1 and +0.1
+		w := (from.LookupTable.randFloat64() * 0.2) - 0.1 // random weight between -0.1 and +0.1
 		weight = &w
 	}
 	conn := &Connection{
@@ -58,6 +57,7 @@ func NewLayerConnection(from, to *Layer, ltype LayerType) LayerConnection {
 		}
 	}
 
+	first := ConnID(len(from.LookupTable.Connections)) // connections from here on are made by this call
 	var list []*Connection
 	connsByID := make(map[ConnID]*Connection)
 	switch ltype {
@@ -100,6 +100,9 @@ func NewLayerConnection(from, to *Layer, ltype LayerType) LayerConnection {
 		Connections: connsByID,
 		List:        list,
 	}
+	if init := from.LookupTable.Initializer; init != nil {
+		lc.initialize(init, first)
+	}
 	from.ConnectedTo = append(from.ConnectedTo, lc)
 
 	return lc
@@ -1,6 +1,7 @@
 package automata
 
 import (
+	"fmt"
 	"math"
 )
 
@@ -10,7 +11,25 @@ type Coster interface {
 	Cost(target, output []float64) (cost float64)
 }
 
-// MeanSquaredErrorCost implements the MSE cost function.
+// Differentiable is implemented by cost functions which can provide their gradient. When the LookupTable's
+// CostFunction is Differentiable, output layers propagate the gradient of the cost rather than the default error
+// signal of (target - activation) for each neuron.
+type Differentiable interface {
+	// Gradient returns the derivative of the cost with respect to each output. The length of target and output
+	// will always be the same.
+	Gradient(target, output []float64) []float64
+}
+
+// elementwiseCoster is implemented by cost functions which add up or average a separate cost for each output.
+type elementwiseCoster interface {
+	// outputCosts returns the contribution of each output to the cost, which add up to the cost.
+	outputCosts(target, output []float64) []float64
+}
+
+// MeanSquaredErrorCost implements the MSE cost function. It is not Differentiable, so output layers propagate the
+// default error signal of (target - activation) as they always have, which keeps training with MSE the same as
+// before cost functions could provide a gradient. For outputs with SquashIdentity this is proportional to the
+// gradient of the cost.
 type MeanSquaredErrorCost struct{}
 
 // Cost of the given output.
@@ -21,6 +40,14 @@ func (c *MeanSquaredErrorCost) Cost(target, output []float64) (cost float64) {
 	return cost / float64(len(output))
 }
 
+func (c *MeanSquaredErrorCost) outputCosts(target, output []float64) []float64 {
+	costs := make([]float64, len(output))
+	for i := range output {
+		costs[i] = math.Pow(target[i]-output[i], 2) / float64(len(output))
+	}
+	return costs
+}
+
 // CrossEntropyCost implement the cross entropy function (Eq. 9)
 type CrossEntropyCost struct{}
 
@@ -35,6 +62,59 @@ func (c *CrossEntropyCost) Cost(target, output []float64) (cost float64) {
 	return
 }
 
+func (c *CrossEntropyCost) outputCosts(target, output []float64) []float64 {
+	nudge := 1e-15
+	costs := make([]float64, len(output))
+	for i := range output {
+		costs[i] = -((target[i] * math.Log(output[i]+nudge)) + ((1 - target[i]) * math.Log((1+nudge)-output[i])))
+	}
+	return costs
+}
+
+// Gradient of the cost with respect to each output.
+func (c *CrossEntropyCost) Gradient(target, output []float64) []float64 {
+	nudge := 1e-15
+	gradient := make([]float64, len(output))
+	for i := range output {
+		gradient[i] = -target[i]/(output[i]+nudge) + (1-target[i])/((1+nudge)-output[i])
+	}
+	return gradient
+}
+
+// CategoricalCrossEntropyCost implements the cross entropy of a probability distribution over several classes, where
+// the target has a 1 for the correct class and a 0 for the others. Use it with a LayerActivationSoftmax output layer:
+// the error each output neuron propagates (target - activation) is then exactly the gradient of this cost with
+// respect to the neuron's state.
+type CategoricalCrossEntropyCost struct{}
+
+// Cost of the given output
+func (c *CategoricalCrossEntropyCost) Cost(target, output []float64) (cost float64) {
+	nudge := 1e-15 // avoid math.Log(0) which = -Inf
+	for i := range output {
+		cost -= target[i] * math.Log(output[i]+nudge)
+	}
+	return
+}
+
+func (c *CategoricalCrossEntropyCost) outputCosts(target, output []float64) []float64 {
+	nudge := 1e-15
+	costs := make([]float64, len(output))
+	for i := range output {
+		costs[i] = -target[i] * math.Log(output[i]+nudge)
+	}
+	return costs
+}
+
+// Gradient of the cost with respect to each output.
+func (c *CategoricalCrossEntropyCost) Gradient(target, output []float64) []float64 {
+	nudge := 1e-15
+	gradient := make([]float64, len(output))
+	for i := range output {
+		gradient[i] = -target[i] / (output[i] + nudge)
+	}
+	return gradient
+}
+
 // BinaryCost implement the binary (Zero-One Loss) function
 type BinaryCost struct{}
 
@@ -52,3 +132,287 @@ func (c *BinaryCost) Cost(target, output []float64) float64 {
 func (c *BinaryCost) round(in float64) float64 {
 	return math.Floor(in + 0.5)
 }
+
+func (c *BinaryCost) outputCosts(target, output []float64) []float64 {
+	costs := make([]float64, len(output))
+	for i := range output {
+		if c.round(target[i]*2) != c.round(output[i]*2) {
+			costs[i] = 1
+		}
+	}
+	return costs
+}
+
+// MeanAbsoluteErrorCost implements the mean absolute error, which is less sensitive to outliers than MSE.
+type MeanAbsoluteErrorCost struct{}
+
+// Cost of the given output.
+func (c *MeanAbsoluteErrorCost) Cost(target, output []float64) float64 {
+	return sum(c.outputCosts(target, output))
+}
+
+func (c *MeanAbsoluteErrorCost) outputCosts(target, output []float64) []float64 {
+	costs := make([]float64, len(output))
+	for i := range output {
+		costs[i] = math.Abs(target[i]-output[i]) / float64(len(output))
+	}
+	return costs
+}
+
+// Gradient of the cost with respect to each output.
+func (c *MeanAbsoluteErrorCost) Gradient(target, output []float64) []float64 {
+	gradient := make([]float64, len(output))
+	for i := range output {
+		gradient[i] = sign(output[i]-target[i]) / float64(len(output))
+	}
+	return gradient
+}
+
+// HuberCost implements the mean Huber loss, which is quadratic for errors smaller than Delta and linear for larger
+// errors, combining the smoothness of MSE with the robustness of MAE.
+type HuberCost struct {
+	// Delta defaults to 1 if 0.
+	Delta float64
+}
+
+// Cost of the given output.
+func (c *HuberCost) Cost(target, output []float64) float64 {
+	return sum(c.outputCosts(target, output))
+}
+
+func (c *HuberCost) outputCosts(target, output []float64) []float64 {
+	delta := defaultFloat(c.Delta, 1)
+	costs := make([]float64, len(output))
+	for i := range output {
+		diff := math.Abs(output[i] - target[i])
+		if diff <= delta {
+			costs[i] = 0.5 * diff * diff
+		} else {
+			costs[i] = delta * (diff - 0.5*delta)
+		}
+		costs[i] /= float64(len(output))
+	}
+	return costs
+}
+
+// Gradient of the cost with respect to each output.
+func (c *HuberCost) Gradient(target, output []float64) []float64 {
+	delta := defaultFloat(c.Delta, 1)
+	gradient := make([]float64, len(output))
+	for i := range output {
+		diff := output[i] - target[i]
+		if math.Abs(diff) > delta {
+			diff = delta * sign(diff)
+		}
+		gradient[i] = diff / float64(len(output))
+	}
+	return gradient
+}
+
+// LogCoshCost implements the mean of log(cosh(output - target)), which behaves like MSE for small errors and MAE
+// for large ones.
+type LogCoshCost struct{}
+
+// Cost of the given output.
+func (c *LogCoshCost) Cost(target, output []float64) float64 {
+	return sum(c.outputCosts(target, output))
+}
+
+func (c *LogCoshCost) outputCosts(target, output []float64) []float64 {
+	costs := make([]float64, len(output))
+	for i := range output {
+		// log(cosh(x)) rearranged so large errors do not overflow
+		x := math.Abs(output[i] - target[i])
+		costs[i] = (x + math.Log1p(math.Exp(-2*x)) - math.Ln2) / float64(len(output))
+	}
+	return costs
+}
+
+// Gradient of the cost with respect to each output.
+func (c *LogCoshCost) Gradient(target, output []float64) []float64 {
+	gradient := make([]float64, len(output))
+	for i := range output {
+		gradient[i] = math.Tanh(output[i]-target[i]) / float64(len(output))
+	}
+	return gradient
+}
+
+// HingeCost implements the mean hinge loss for binary classification. Targets should be -1 or 1, and outputs are
+// penalised unless they have the same sign as the target and a magnitude of at least 1, so outputs should use a
+// squasher like SquashTanh or SquashIdentity.
+type HingeCost struct{}
+
+// Cost of the given output.
+func (c *HingeCost) Cost(target, output []float64) float64 {
+	return sum(c.outputCosts(target, output))
+}
+
+func (c *HingeCost) outputCosts(target, output []float64) []float64 {
+	costs := make([]float64, len(output))
+	for i := range output {
+		costs[i] = math.Max(0, 1-target[i]*output[i]) / float64(len(output))
+	}
+	return costs
+}
+
+// Gradient of the cost with respect to each output.
+func (c *HingeCost) Gradient(target, output []float64) []float64 {
+	gradient := make([]float64, len(output))
+	for i := range output {
+		if 1-target[i]*output[i] > 0 {
+			gradient[i] = -target[i] / float64(len(output))
+		}
+	}
+	return gradient
+}
+
+// KLDivergenceCost implements the Kullback-Leibler divergence of the output from the target, where both are
+// probability distributions, such as the output of a LayerActivationSoftmax layer.
+type KLDivergenceCost struct{}
+
+// Cost of the given output.
+func (c *KLDivergenceCost) Cost(target, output []float64) float64 {
+	return sum(c.outputCosts(target, output))
+}
+
+func (c *KLDivergenceCost) outputCosts(target, output []float64) []float64 {
+	nudge := 1e-15
+	costs := make([]float64, len(output))
+	for i := range output {
+		if target[i] > 0 { // 0 * log(0) is taken to be 0
+			costs[i] = target[i] * math.Log(target[i]/(output[i]+nudge))
+		}
+	}
+	return costs
+}
+
+// Gradient of the cost with respect to each output.
+func (c *KLDivergenceCost) Gradient(target, output []float64) []float64 {
+	nudge := 1e-15
+	gradient := make([]float64, len(output))
+	for i := range output {
+		gradient[i] = -target[i] / (output[i] + nudge)
+	}
+	return gradient
+}
+
+// MeanAbsolutePercentageErrorCost implements the mean absolute percentage error, which measures the error
+// relative to the size of the target. Targets should not be 0.
+type MeanAbsolutePercentageErrorCost struct{}
+
+// Cost of the given output.
+func (c *MeanAbsolutePercentageErrorCost) Cost(target, output []float64) float64 {
+	return sum(c.outputCosts(target, output))
+}
+
+func (c *MeanAbsolutePercentageErrorCost) outputCosts(target, output []float64) []float64 {
+	costs := make([]float64, len(output))
+	for i := range output {
+		costs[i] = 100 * math.Abs(target[i]-output[i]) / c.scale(target[i]) / float64(len(output))
+	}
+	return costs
+}
+
+// Gradient of the cost with respect to each output.
+func (c *MeanAbsolutePercentageErrorCost) Gradient(target, output []float64) []float64 {
+	gradient := make([]float64, len(output))
+	for i := range output {
+		gradient[i] = 100 * sign(output[i]-target[i]) / c.scale(target[i]) / float64(len(output))
+	}
+	return gradient
+}
+
+// scale returns the size of the target, nudged away from 0 to avoid dividing by 0.
+func (c *MeanAbsolutePercentageErrorCost) scale(target float64) float64 {
+	return math.Max(math.Abs(target), 1e-15)
+}
+
+// CosineDistanceCost implements 1 minus the cosine similarity of the output and target, which only depends on
+// the direction of the output and not its magnitude. It cannot be used with WeightedCost.
+type CosineDistanceCost struct{}
+
+// Cost of the given output.
+func (c *CosineDistanceCost) Cost(target, output []float64) float64 {
+	dot, targetNorm, outputNorm := c.norms(target, output)
+	return 1 - dot/(targetNorm*outputNorm)
+}
+
+// Gradient of the cost with respect to each output.
+func (c *CosineDistanceCost) Gradient(target, output []float64) []float64 {
+	dot, targetNorm, outputNorm := c.norms(target, output)
+	gradient := make([]float64, len(output))
+	for i := range output {
+		gradient[i] = -(target[i]/(targetNorm*outputNorm) - dot*output[i]/(targetNorm*math.Pow(outputNorm, 3)))
+	}
+	return gradient
+}
+
+// norms returns the dot product of the target and output and their lengths, nudged away from 0.
+func (c *CosineDistanceCost) norms(target, output []float64) (dot, targetNorm, outputNorm float64) {
+	for i := range output {
+		dot += target[i] * output[i]
+		targetNorm += target[i] * target[i]
+		outputNorm += output[i] * output[i]
+	}
+	return dot, math.Max(math.Sqrt(targetNorm), 1e-15), math.Max(math.Sqrt(outputNorm), 1e-15)
+}
+
+// WeightedCost weights how much each output contributes to a cost function, so that some outputs of a network
+// can be treated as more important than others. Both the cost and the error signal each output neuron propagates
+// are multiplied by the weight for that output. Use NewWeightedCost to create one.
+type WeightedCost struct {
+	// Coster is the cost function to weight. It must add up or average a cost for each output, which is true of
+	// every built-in cost function apart from CosineDistanceCost.
+	Coster Coster
+	// Weights has one weight for each output.
+	Weights []float64
+}
+
+// NewWeightedCost weights each output of the cost function by the given weights.
+func NewWeightedCost(cost Coster, weights []float64) (*WeightedCost, error) {
+	if _, ok := cost.(elementwiseCoster); !ok {
+		return nil, fmt.Errorf("NewWeightedCost: cost function %T cannot be weighted per output", cost)
+	}
+	return &WeightedCost{Coster: cost, Weights: weights}, nil
+}
+
+// check returns an error if the cost function cannot be weighted per output, or if there is not one weight for
+// each of the given number of outputs.
+func (c *WeightedCost) check(outputs int) error {
+	if _, ok := c.Coster.(elementwiseCoster); !ok {
+		return fmt.Errorf("cost function %T cannot be weighted per output", c.Coster)
+	}
+	if len(c.Weights) != outputs {
+		return fmt.Errorf("%d cost weights for %d outputs", len(c.Weights), outputs)
+	}
+	return nil
+}
+
+// Cost of the given output. Returns NaN if the cost function cannot be weighted or there is not one weight for each
+// output. Trainer.Train checks both before training, so a NaN cost never reaches the error rate.
+func (c *WeightedCost) Cost(target, output []float64) (cost float64) {
+	if c.check(len(output)) != nil {
+		return math.NaN()
+	}
+	for i, oc := range c.Coster.(elementwiseCoster).outputCosts(target, output) {
+		cost += c.Weights[i] * oc
+	}
+	return
+}
+
+func sum(values []float64) (total float64) {
+	for _, v := range values {
+		total += v
+	}
+	return
+}
+
+func sign(x float64) float64 {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	}
+	return 0
+}
@@ -1,6 +1,8 @@
 package automata
 
 import (
+	"math"
+	"math/rand"
 	"testing"
 )
 
@@ -48,3 +50,214 @@ func TestBinaryCost(t *testing.T) {
 		t.Errorf("Cost([0, 0.5, 1], [0, 0.5, 1]) : want %f, got %f", want, output)
 	}
 }
+
+func TestCategoricalCrossEntropyCost(t *testing.T) {
+	cce := CategoricalCrossEntropyCost{}
+	if got, want := cce.Cost([]float64{0, 1, 0}, []float64{0.2, 0.5, 0.3}), -math.Log(0.5); math.Abs(got-want) > 1e-12 {
+		t.Errorf("Cost([0, 1, 0], [0.2, 0.5, 0.3]) : want %f, got %f", want, got)
+	}
+}
+
+func TestRegressionCosts(t *testing.T) {
+	target, output := []float64{1, 2, -1}, []float64{1.5, 0, -1}
+	testCases := []struct {
+		name           string
+		cost           Coster
+		target, output []float64
+		want           float64
+	}{
+		{"mae", &MeanAbsoluteErrorCost{}, target, output, 2.5 / 3},
+		{"huber", &HuberCost{}, target, output, (0.125 + 1.5) / 3},
+		{"log cosh", &LogCoshCost{}, target, output, (math.Log(math.Cosh(0.5)) + math.Log(math.Cosh(2))) / 3},
+		{"hinge", &HingeCost{}, target, output, 1.0 / 3},
+		{"mape", &MeanAbsolutePercentageErrorCost{}, target, output, (50 + 100 + 0) / 3.0},
+		{"cosine distance", &CosineDistanceCost{}, target, output, 1 - 2.5/(math.Sqrt(6)*math.Sqrt(3.25))},
+		{"kl divergence", &KLDivergenceCost{}, []float64{0, 0.5, 0.5}, []float64{0.2, 0.4, 0.4}, math.Log(0.5 / 0.4)},
+	}
+	for _, tc := range testCases {
+		if got := tc.cost.Cost(tc.target, tc.output); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("%s: Cost(%v, %v) : want %f, got %f", tc.name, tc.target, tc.output, tc.want, got)
+		}
+		if got := tc.cost.Cost(tc.target, tc.target); math.Abs(got) > 1e-9 {
+			t.Errorf("%s: Cost(%v, %v) : want 0, got %f", tc.name, tc.target, tc.target, got)
+		}
+	}
+}
+
+func TestWeightedCost(t *testing.T) {
+	target, output := []float64{0, 0.5, 1}, []float64{1, 0.5, 0}
+	weighted, err := NewWeightedCost(&MeanSquaredErrorCost{}, []float64{2, 1, 0})
+	if err != nil {
+		t.Fatalf("NewWeightedCost threw error: %s", err.Error())
+	}
+	if got, want := weighted.Cost(target, output), 2.0/3; math.Abs(got-want) > 1e-12 {
+		t.Errorf("Cost(%v, %v) : want %f, got %f", target, output, want, got)
+	}
+	if _, err = NewWeightedCost(&CosineDistanceCost{}, []float64{1, 1, 1}); err == nil {
+		t.Errorf("NewWeightedCost: expected error weighting cosine distance, got nil")
+	}
+
+	// outputs with a weight of 0 should not propagate any error with the classic error signal
+	table := &LookupTable{CostFunction: weighted}
+	inputLayer := NewLayer(table, 2)
+	outputLayer := NewLayer(table, 3)
+	inputLayer.Project(&outputLayer, LayerTypeAuto)
+	network := Network{
+		Input:  &inputLayer,
+		Output: &outputLayer,
+	}
+	output, _ = network.Activate([]float64{0.8, -0.4})
+	if err = network.Propagate(0, target); err != nil {
+		t.Fatalf("Propagate threw error: %s", err.Error())
+	}
+	for i, neuron := range outputLayer.List {
+		want := weighted.Weights[i] * (target[i] - output[i])
+		if neuron.ErrorResponsibility != want {
+			t.Errorf("neuron %d: want error responsibility %v, got %v", i, want, neuron.ErrorResponsibility)
+		}
+	}
+
+	weighted.Weights = []float64{1, 1}
+	if err = network.Propagate(0, target); err == nil {
+		t.Errorf("Propagate: expected error with too few cost weights, got nil")
+	}
+	if got := weighted.Cost(target, output); !math.IsNaN(got) {
+		t.Errorf("Cost: want NaN with too few cost weights, got %v", got)
+	}
+	unweightable := &WeightedCost{Coster: &CosineDistanceCost{}, Weights: []float64{1, 1, 1}}
+	if got := unweightable.Cost(target, output); !math.IsNaN(got) {
+		t.Errorf("Cost: want NaN weighting cosine distance, got %v", got)
+	}
+	table.CostFunction = unweightable
+	if err = network.Propagate(0, target); err == nil {
+		t.Errorf("Propagate: expected error weighting cosine distance, got nil")
+	}
+	trainer := Trainer{
+		Network:      &network,
+		LearnRate:    0.1,
+		Iterations:   1,
+		CostFunction: weighted,
+	}
+	if _, err = trainer.Train([]TrainSet{{[]float64{0.8, -0.4}, target}}); err == nil {
+		t.Errorf("Train: expected error with too few cost weights, got nil")
+	}
+}
+
+func TestSoftmaxGradient(t *testing.T) {
+	table := &LookupTable{}
+	inputLayer := NewLayer(table, 2)
+	outputLayer := NewLayer(table, 3)
+	outputLayer.Activation = LayerActivationSoftmax
+	inputLayer.Project(&outputLayer, LayerTypeAuto)
+	network := Network{
+		Input:  &inputLayer,
+		Output: &outputLayer,
+	}
+	input, target := []float64{0.3, -0.7}, []float64{0, 0, 1}
+	cost := func() float64 {
+		output, _ := network.Activate(input)
+		return (&CategoricalCrossEntropyCost{}).Cost(target, output)
+	}
+
+	output, _ := network.Activate(input)
+	var sum float64
+	for _, o := range output {
+		sum += o
+	}
+	if math.Abs(sum-1) > 1e-12 {
+		t.Errorf("softmax outputs should add up to 1, got %v (%v)", sum, output)
+	}
+
+	// a bias is added straight to the state, so the gradient of the cost with respect to each bias is the
+	// gradient with respect to the state, which should be the negative of the error the neuron propagates
+	network.Propagate(0, target)
+	for _, neuron := range outputLayer.List {
+		bias := neuron.Bias
+		neuron.Bias = bias + 1e-6
+		up := cost()
+		neuron.Bias = bias - 1e-6
+		down := cost()
+		neuron.Bias = bias
+		numerical := (up - down) / 2e-6
+		if math.Abs(numerical+neuron.ErrorResponsibility) > 1e-6 {
+			t.Errorf("neuron %d: want gradient %v, got error responsibility %v", neuron.ID, numerical, neuron.ErrorResponsibility)
+		}
+	}
+}
+
+// quarticCost is a custom Differentiable cost used to check gradients are propagated for any cost function.
+type quarticCost struct{}
+
+func (c *quarticCost) Cost(target, output []float64) (cost float64) {
+	for i := range output {
+		cost += math.Pow(target[i]-output[i], 4)
+	}
+	return
+}
+
+func (c *quarticCost) Gradient(target, output []float64) []float64 {
+	gradient := make([]float64, len(output))
+	for i := range output {
+		gradient[i] = -4 * math.Pow(target[i]-output[i], 3)
+	}
+	return gradient
+}
+
+func TestDifferentiablePropagate(t *testing.T) {
+	testCases := []struct {
+		name       string
+		cost       Coster
+		squash     Squasher
+		activation LayerActivation
+		target     []float64
+	}{
+		{"cross entropy", &CrossEntropyCost{}, &SquashLogistic{}, LayerActivationNeurons, []float64{0, 1, 1}},
+		{"cross entropy gaussian", &CrossEntropyCost{}, &SquashGaussian{}, LayerActivationNeurons, []float64{0, 1, 1}},
+		{"categorical cross entropy", &CategoricalCrossEntropyCost{}, &SquashLogistic{}, LayerActivationSoftmax, []float64{0, 1, 0}},
+		{"custom tanh", &quarticCost{}, &SquashTanh{}, LayerActivationNeurons, []float64{-1, 0.5, 1}},
+		{"custom softmax", &quarticCost{}, &SquashLogistic{}, LayerActivationSoftmax, []float64{0, 1, 0}},
+		{"mae", &MeanAbsoluteErrorCost{}, &SquashLogistic{}, LayerActivationNeurons, []float64{0, 1, 1}},
+		{"huber", &HuberCost{Delta: 0.3}, &SquashIdentity{}, LayerActivationNeurons, []float64{-1, 0.5, 1}},
+		{"log cosh", &LogCoshCost{}, &SquashTanh{}, LayerActivationNeurons, []float64{-1, 0.5, 1}},
+		{"hinge", &HingeCost{}, &SquashTanh{}, LayerActivationNeurons, []float64{-1, 1, 1}},
+		{"kl divergence", &KLDivergenceCost{}, &SquashLogistic{}, LayerActivationSoftmax, []float64{0.2, 0.5, 0.3}},
+		{"mape", &MeanAbsolutePercentageErrorCost{}, &SquashLogistic{}, LayerActivationNeurons, []float64{0.1, 0.9, 2}},
+		{"cosine distance", &CosineDistanceCost{}, &SquashTanh{}, LayerActivationNeurons, []float64{-1, 0.5, 1}},
+		{"weighted cross entropy", &WeightedCost{&CrossEntropyCost{}, []float64{0.5, 2, 0}}, &SquashLogistic{}, LayerActivationNeurons, []float64{0, 1, 1}},
+		{"weighted softmax", &WeightedCost{&CategoricalCrossEntropyCost{}, []float64{1, 3, 1}}, &SquashLogistic{}, LayerActivationSoftmax, []float64{0, 1, 0}},
+	}
+	for _, tc := range testCases {
+		table := &LookupTable{CostFunction: tc.cost, Rand: rand.New(rand.NewSource(1))}
+		inputLayer := NewLayer(table, 2)
+		outputLayer := NewLayer(table, 3)
+		outputLayer.Activation = tc.activation
+		for _, neuron := range outputLayer.List {
+			neuron.Squash = tc.squash
+		}
+		inputLayer.Project(&outputLayer, LayerTypeAuto)
+		network := Network{
+			Input:  &inputLayer,
+			Output: &outputLayer,
+		}
+		input := []float64{0.8, -0.4}
+		cost := func() float64 {
+			output, _ := network.Activate(input)
+			return tc.cost.Cost(tc.target, output)
+		}
+		network.Activate(input)
+		network.Propagate(0, tc.target)
+		for _, neuron := range outputLayer.List {
+			bias := neuron.Bias
+			neuron.Bias = bias + 1e-6
+			up := cost()
+			neuron.Bias = bias - 1e-6
+			down := cost()
+			neuron.Bias = bias
+			numerical := (up - down) / 2e-6
+			// relative to the size of the gradient, as the numerical gradient is less precise where it is steep
+			if math.Abs(numerical+neuron.ErrorResponsibility) > 1e-5*math.Max(1, math.Abs(numerical)) {
+				t.Errorf("%s: neuron %d: want gradient %v, got error responsibility %v", tc.name, neuron.ID, numerical, neuron.ErrorResponsibility)
+			}
+		}
+	}
+}
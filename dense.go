@@ -0,0 +1,240 @@
+package automata
+
+import "fmt"
+
+// denseNetwork is the matrix form of a Network where each layer is connected all-to-all to the next and to
+// nothing else, with no gates or self-connections. In such networks the eligibility trace of every connection is
+// just the activation of the neuron it comes from, so activating and propagating are matrix-vector products with
+// no trace bookkeeping. The weights stay on the connections, so the rest of the package sees the same network.
+type denseNetwork struct {
+	ok       bool // false if the network cannot be treated as dense
+	table    *LookupTable
+	topology uint64      // the table's topology when this was made
+	layers   []*Layer    // every layer in the network, to check the network has not changed
+	lists    [][]*Neuron // the neurons in every layer, to check the layers have not changed
+	dense    []denseLayer
+}
+
+// denseLayer is a layer in a denseNetwork after the input layer.
+type denseLayer struct {
+	layer   *Layer
+	from    []*Neuron
+	to      []*Neuron
+	weights []*Connection // row-major: weights[i*len(from)+j] connects from[j] to to[i]
+	scratch []float64
+}
+
+// denseNetwork returns the dense form of the network, or nil if it cannot be treated as dense. The result is
+// cached until the layers of the network or the topology of its LookupTable change.
+func (n *Network) denseNetwork() *denseNetwork {
+	if n.dense == nil || !n.dense.current(n) {
+		n.dense = newDenseNetwork(n)
+	}
+	if !n.dense.ok {
+		return nil
+	}
+	return n.dense
+}
+
+// newDenseNetwork makes the dense form of the network.
+func newDenseNetwork(n *Network) *denseNetwork {
+	table := n.LookupTable()
+	d := &denseNetwork{
+		table:    table,
+		topology: table.topology,
+		layers:   n.layers(),
+	}
+	for _, layer := range d.layers {
+		d.lists = append(d.lists, layer.List)
+	}
+	for _, layer := range d.layers {
+		if len(layer.List) == 0 {
+			return d
+		}
+		for _, neuron := range layer.List {
+			if len(neuron.Gated) > 0 || neuron.Self.Weight != 0 {
+				return d
+			}
+		}
+	}
+	for k := 1; k < len(d.layers); k++ {
+		from, to := d.layers[k-1], d.layers[k]
+		dl := denseLayer{
+			layer:   to,
+			from:    from.List,
+			to:      to.List,
+			weights: make([]*Connection, 0, len(from.List)*len(to.List)),
+			scratch: make([]float64, len(to.List)),
+		}
+		for _, neuron := range to.List {
+			if len(neuron.Inputs) != len(from.List) {
+				return d
+			}
+			for j, connID := range neuron.Inputs {
+				conn := table.GetConnection(connID)
+				if conn.From != from.List[j] || conn.Gater != nil || conn.Gain != 1 {
+					return d
+				}
+				dl.weights = append(dl.weights, conn)
+			}
+		}
+		// hidden neurons get their error from the neurons they project to, which must only be the next layer
+		for _, neuron := range from.List {
+			if k == 1 {
+				break
+			}
+			if len(neuron.Projected) != len(to.List) {
+				return d
+			}
+			for r, connID := range neuron.Projected {
+				if table.GetConnection(connID).To != to.List[r] {
+					return d
+				}
+			}
+		}
+		d.dense = append(d.dense, dl)
+	}
+	for _, neuron := range n.Output.List {
+		if len(neuron.Projected) > 0 {
+			return d
+		}
+	}
+	d.ok = true
+	return d
+}
+
+// current returns true if the network and its table have not changed since this was made.
+func (d *denseNetwork) current(n *Network) bool {
+	table := n.LookupTable()
+	if d.table != table || d.topology != table.topology || len(d.layers) != len(n.Hidden)+2 {
+		return false
+	}
+	if d.layers[0] != n.Input || d.layers[len(d.layers)-1] != n.Output {
+		return false
+	}
+	for i := range n.Hidden {
+		if d.layers[i+1] != &n.Hidden[i] {
+			return false
+		}
+	}
+	for i, layer := range d.layers {
+		list := d.lists[i]
+		if len(layer.List) != len(list) || (len(list) > 0 && &layer.List[0] != &list[0]) {
+			return false
+		}
+	}
+	return true
+}
+
+// activate the network with the given input, in the same way as Network.Activate.
+func (d *denseNetwork) activate(input []float64) ([]float64, error) {
+	inputs := d.layers[0].List
+	if len(input) != len(inputs) {
+		return nil, fmt.Errorf("input and layer size mismatch: cannot activate")
+	}
+	for i, neuron := range inputs {
+		neuron.Activate(&input[i])
+	}
+	var activations []float64
+	for k := range d.dense {
+		dl := &d.dense[k]
+		activations = dl.scratch
+		if k == len(d.dense)-1 {
+			activations = make([]float64, len(dl.to)) // the output is returned so cannot be reused
+		}
+		dl.activate(activations)
+	}
+	return activations, nil
+}
+
+// activate the layer, putting the activation of each neuron in 'activations'.
+func (dl *denseLayer) activate(activations []float64) {
+	activate := func(i int) {
+		neuron := dl.to[i]
+		neuron.Old = neuron.State
+		// Eq. 15
+		state := neuron.Self.Gain*neuron.Self.Weight*neuron.State + neuron.Bias
+		for j, conn := range dl.weights[i*len(dl.from) : (i+1)*len(dl.from)] {
+			state += dl.from[j].Activation * conn.Weight
+		}
+		neuron.State = state
+		// Eq. 16
+		neuron.Activation = neuron.Squash.Squash(state, false)
+		neuron.Derivative = neuron.Squash.Squash(state, true)
+		activations[i] = neuron.Activation
+	}
+	if workers := dl.layer.LookupTable.Workers; workers > 1 {
+		parallel(workers, len(dl.to), activate)
+	} else {
+		for i := range dl.to {
+			activate(i)
+		}
+	}
+	if dl.layer.Activation == LayerActivationSoftmax {
+		dl.layer.softmax(activations)
+	}
+}
+
+// propagate the error for the given target through the network, in the same way as Network.Propagate.
+func (d *denseNetwork) propagate(rate float64, target []float64) error {
+	output := &d.dense[len(d.dense)-1]
+	errs, err := output.layer.targetErrors(target)
+	if err != nil {
+		return err
+	}
+	output.each(func(i int) {
+		output.to[i].ErrorResponsibility = errs[i]
+		output.to[i].ErrorProjected = errs[i]
+		output.learn(rate, i)
+	})
+	for k := len(d.dense) - 2; k >= 0; k-- {
+		dl, next := &d.dense[k], &d.dense[k+1]
+		dl.each(func(i int) {
+			neuron := dl.to[i]
+			// Eq. 21: the column of the next layer's weights for this neuron
+			var accumulatedError float64
+			for r, to := range next.to {
+				accumulatedError += to.ErrorResponsibility * next.weights[r*len(next.from)+i].Weight
+			}
+			neuron.ErrorProjected = neuron.Derivative * accumulatedError
+			neuron.ErrorGated = 0
+			neuron.ErrorResponsibility = neuron.ErrorProjected
+			dl.learn(rate, i)
+		})
+	}
+	return nil
+}
+
+// each calls f for every neuron in the layer while propagating, either concurrently if the LookupTable has more
+// than one worker, or one at a time in reverse order like Layer.Propagate.
+func (dl *denseLayer) each(f func(i int)) {
+	table := dl.layer.LookupTable
+	if table.Workers > 1 {
+		table.reserve(dl.to)
+		parallel(table.Workers, len(dl.to), f)
+		return
+	}
+	for i := len(dl.to) - 1; i >= 0; i-- {
+		f(i)
+	}
+}
+
+// learn adjusts the weights and bias of the i'th neuron in the layer, or accumulates their gradients if the
+// LookupTable is accumulating gradients, in the same way as Neuron.learn.
+func (dl *denseLayer) learn(rate float64, i int) {
+	neuron := dl.to[i]
+	table := neuron.LookupTable
+	row := dl.weights[i*len(dl.from) : (i+1)*len(dl.from)]
+	if table.AccumulateGradients {
+		for j, conn := range row {
+			table.accumulateWeight(conn.ID, neuron.ErrorProjected*dl.from[j].Activation)
+		}
+		table.accumulateBias(neuron.ID, neuron.ErrorResponsibility)
+		return
+	}
+	for j, conn := range row {
+		// Eq. 24: the eligibility trace is the activation of the neuron the connection comes from
+		conn.Weight += table.weightDelta(conn.ID, rate, neuron.ErrorProjected*dl.from[j].Activation)
+	}
+	neuron.Bias += table.biasDelta(neuron.ID, rate, neuron.ErrorResponsibility)
+}
@@ -0,0 +1,110 @@
+package automata
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func densePerceptron(t testing.TB, sizes []int) *Network {
+	table := &LookupTable{Rand: rand.New(rand.NewSource(1))}
+	network, err := NewPerceptronNetwork(table, sizes)
+	if err != nil {
+		t.Fatalf("NewPerceptronNetwork threw error: %s", err.Error())
+	}
+	return network
+}
+
+func TestDenseNetwork(t *testing.T) {
+	testCases := []struct {
+		name       string
+		cost       Coster
+		optimizer  Optimizer
+		activation LayerActivation
+		batch      bool
+	}{
+		{"mse", &MeanSquaredErrorCost{}, nil, LayerActivationNeurons, false},
+		{"softmax adam", &CategoricalCrossEntropyCost{}, &AdamOptimizer{}, LayerActivationSoftmax, false},
+		{"batch", &CrossEntropyCost{}, &MomentumOptimizer{}, LayerActivationNeurons, true},
+	}
+	sets := []TrainSet{
+		{[]float64{0, 0, 1}, []float64{1, 0}},
+		{[]float64{0, 1, 0}, []float64{0, 1}},
+		{[]float64{1, 0.5, 0}, []float64{1, 0}},
+	}
+	for _, tc := range testCases {
+		dense, neurons := densePerceptron(t, []int{3, 5, 4, 2}), densePerceptron(t, []int{3, 5, 4, 2})
+		for _, network := range []*Network{dense, neurons} {
+			network.Output.Activation = tc.activation
+			table := network.LookupTable()
+			table.CostFunction, table.Optimizer, table.AccumulateGradients = tc.cost, tc.optimizer, tc.batch
+		}
+		if dense.denseNetwork() == nil {
+			t.Fatalf("%s: want a perceptron to be dense", tc.name)
+		}
+		for iter := 0; iter < 5; iter++ {
+			for _, set := range sets {
+				want, _ := neurons.activateNeurons(set.Input)
+				got, err := dense.Activate(set.Input)
+				if err != nil {
+					t.Fatalf("%s: Activate threw error: %s", tc.name, err.Error())
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Fatalf("%s: want output %v, got %v", tc.name, want, got)
+				}
+				neurons.propagateNeurons(0.3, set.Output)
+				if err = dense.Propagate(0.3, set.Output); err != nil {
+					t.Fatalf("%s: Propagate threw error: %s", tc.name, err.Error())
+				}
+			}
+			if tc.batch {
+				neurons.LookupTable().ApplyGradients(0.3, len(sets))
+				dense.LookupTable().ApplyGradients(0.3, len(sets))
+			}
+		}
+		want, got := neurons.LookupTable().saveParameters(), dense.LookupTable().saveParameters()
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: want parameters %v, got %v", tc.name, want, got)
+		}
+	}
+}
+
+func TestDenseNetworkFallback(t *testing.T) {
+	network := densePerceptron(t, []int{2, 3, 1})
+	if network.denseNetwork() == nil {
+		t.Fatalf("want a perceptron to be dense")
+	}
+	// gating a connection changes the topology so the network is no longer dense
+	gater := NewNeuron(network.LookupTable())
+	gater.Gate(network.LookupTable().GetConnection(network.Output.List[0].Inputs[0]))
+	if network.denseNetwork() != nil {
+		t.Errorf("want a gated perceptron not to be dense")
+	}
+
+	lstm := NewLSTM(&LookupTable{}, 2, []int{3}, 1)
+	if lstm.denseNetwork() != nil {
+		t.Errorf("want an LSTM not to be dense")
+	}
+}
+
+func benchmarkPerceptron(b *testing.B, activate func(*Network, []float64) ([]float64, error), propagate func(*Network, float64, []float64) error) {
+	network := densePerceptron(b, []int{32, 64, 64, 8})
+	input, target := make([]float64, 32), make([]float64, 8)
+	for i := range input {
+		input[i] = float64(i%3) / 2
+	}
+	target[3] = 1
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		activate(network, input)
+		propagate(network, 0.1, target)
+	}
+}
+
+func BenchmarkPerceptronDense(b *testing.B) {
+	benchmarkPerceptron(b, (*Network).Activate, (*Network).Propagate)
+}
+
+func BenchmarkPerceptronNeurons(b *testing.B) {
+	benchmarkPerceptron(b, (*Network).activateNeurons, (*Network).propagateNeurons)
+}
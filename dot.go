@@ -0,0 +1,178 @@
+package automata
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+)
+
+// DOTOptions control how a network is rendered by WriteDOT.
+type DOTOptions struct {
+	// CollapseLayers renders each layer as a single node rather than rendering every neuron. Connections between
+	// two layers are merged into a single edge. This is useful for large networks.
+	CollapseLayers bool
+}
+
+// WriteDOT renders the topology of the network in the Graphviz DOT language. Layers are drawn as clusters of
+// neurons. Connections are coloured blue for positive weights and red for negative weights, with thicker edges
+// for larger weights. Gated connections are shown by a dashed grey edge from the gater to the neuron receiving
+// the gated connection. Self-connections are drawn as loops.
+//
+// The output can be rendered with Graphviz, e.g: dot -Tsvg network.dot > network.svg
+func (n *Network) WriteDOT(w io.Writer, opts DOTOptions) error {
+	layers := n.layers()
+	layerOf := make(map[NeuronID]int)
+	for i, layer := range layers {
+		for _, neuron := range layer.List {
+			layerOf[neuron.ID] = i
+		}
+	}
+	// every connection in the network, in a stable order
+	var conns []*Connection
+	for _, layer := range layers {
+		for _, neuron := range layer.List {
+			if neuron.Self.Weight != 0 {
+				conns = append(conns, neuron.Self)
+			}
+			for _, connID := range neuron.Inputs {
+				conn := n.LookupTable().GetConnection(connID)
+				if _, ok := layerOf[conn.From.ID]; ok {
+					conns = append(conns, conn)
+				}
+			}
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph network {")
+	fmt.Fprintln(bw, "\trankdir=LR;")
+	if opts.CollapseLayers {
+		writeDOTCollapsed(bw, layers, layerOf, conns)
+	} else {
+		writeDOTNeurons(bw, layers, layerOf, conns)
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+func writeDOTNeurons(w io.Writer, layers []*Layer, layerOf map[NeuronID]int, conns []*Connection) {
+	var maxWeight float64
+	for _, conn := range conns {
+		maxWeight = math.Max(maxWeight, math.Abs(conn.Weight))
+	}
+
+	for i, layer := range layers {
+		fmt.Fprintf(w, "\tsubgraph cluster_%d {\n", i)
+		fmt.Fprintf(w, "\t\tlabel=%q;\n", dotLayerName(layers, i))
+		for _, neuron := range layer.List {
+			fmt.Fprintf(w, "\t\tn%d [label=%q];\n", neuron.ID,
+				fmt.Sprintf("%d\nbias=%.4g\n%s", neuron.ID, neuron.Bias, dotSquasherName(neuron.Squash)))
+		}
+		fmt.Fprintln(w, "\t}")
+	}
+
+	for _, conn := range conns {
+		fmt.Fprintf(w, "\tn%d -> n%d [%s];\n", conn.From.ID, conn.To.ID, dotWeightAttrs(conn.Weight, maxWeight))
+		if conn.Gater == nil {
+			continue
+		}
+		if _, ok := layerOf[conn.Gater.ID]; ok {
+			fmt.Fprintf(w, "\tn%d -> n%d [style=dashed, color=gray50, arrowhead=odot, tooltip=\"gates %d -> %d\"];\n",
+				conn.Gater.ID, conn.To.ID, conn.From.ID, conn.To.ID)
+		}
+	}
+}
+
+func writeDOTCollapsed(w io.Writer, layers []*Layer, layerOf map[NeuronID]int, conns []*Connection) {
+	type layerEdge struct {
+		from, to int
+		gate     bool
+	}
+	type edgeStats struct {
+		count       int
+		totalWeight float64
+	}
+	var edges []layerEdge
+	stats := make(map[layerEdge]*edgeStats)
+	add := func(edge layerEdge, weight float64) {
+		s, ok := stats[edge]
+		if !ok {
+			s = &edgeStats{}
+			stats[edge] = s
+			edges = append(edges, edge)
+		}
+		s.count++
+		s.totalWeight += weight
+	}
+	for _, conn := range conns {
+		to := layerOf[conn.To.ID]
+		add(layerEdge{from: layerOf[conn.From.ID], to: to}, conn.Weight)
+		if conn.Gater == nil {
+			continue
+		}
+		if gater, ok := layerOf[conn.Gater.ID]; ok {
+			add(layerEdge{from: gater, to: to, gate: true}, 0)
+		}
+	}
+
+	// edges are weighted by the mean weight of the connections they represent
+	var maxWeight float64
+	for _, edge := range edges {
+		if !edge.gate {
+			maxWeight = math.Max(maxWeight, math.Abs(stats[edge].totalWeight/float64(stats[edge].count)))
+		}
+	}
+
+	for i, layer := range layers {
+		fmt.Fprintf(w, "\tl%d [shape=box, label=%q];\n", i,
+			fmt.Sprintf("%s\n%d neurons", dotLayerName(layers, i), len(layer.List)))
+	}
+	for _, edge := range edges {
+		s := stats[edge]
+		if edge.gate {
+			fmt.Fprintf(w, "\tl%d -> l%d [style=dashed, color=gray50, arrowhead=odot, label=\"gates %d\"];\n",
+				edge.from, edge.to, s.count)
+			continue
+		}
+		fmt.Fprintf(w, "\tl%d -> l%d [%s, label=\"%d\"];\n",
+			edge.from, edge.to, dotWeightAttrs(s.totalWeight/float64(s.count), maxWeight), s.count)
+	}
+}
+
+// dotWeightAttrs returns edge attributes for a connection with the given weight, where 'maxWeight' is the
+// largest absolute weight being drawn.
+func dotWeightAttrs(weight, maxWeight float64) string {
+	colour := "blue"
+	if weight < 0 {
+		colour = "red"
+	}
+	penwidth := 1.0
+	if maxWeight > 0 {
+		penwidth += 4 * math.Abs(weight) / maxWeight
+	}
+	return fmt.Sprintf("color=%s, penwidth=%.2f, tooltip=\"%.4g\"", colour, penwidth, weight)
+}
+
+func dotLayerName(layers []*Layer, i int) string {
+	var name string
+	switch i {
+	case 0:
+		name = "input"
+	case len(layers) - 1:
+		name = "output"
+	default:
+		name = fmt.Sprintf("hidden %d", i-1)
+	}
+	if layers[i].Activation == LayerActivationSoftmax {
+		name += " (softmax)"
+	}
+	return name
+}
+
+func dotSquasherName(s Squasher) string {
+	if name, err := SquasherName(s); err == nil {
+		return name
+	}
+	return fmt.Sprintf("%T", s)
+}
@@ -0,0 +1,59 @@
+package automata_test
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/Kegsay/automata"
+	"strings"
+	"testing"
+)
+
+func TestWriteDOT(t *testing.T) {
+	testLookupTable := &automata.LookupTable{}
+	lstm := automata.NewLSTM(testLookupTable, 1, []int{2}, 1)
+	var buf bytes.Buffer
+	if err := lstm.WriteDOT(&buf, automata.DOTOptions{}); err != nil {
+		t.Fatalf("WriteDOT threw error: %s", err.Error())
+	}
+	dot := buf.String()
+
+	memoryCell := lstm.Hidden[2].List[0]
+	forgetGate := lstm.Hidden[1].List[0]
+	for _, want := range []string{
+		"digraph network {",
+		"subgraph cluster_0 {",
+		`label="hidden 3";`,
+		fmt.Sprintf("n%d -> n%d [color=", memoryCell.ID, memoryCell.ID),                  // self-connection
+		fmt.Sprintf("n%d -> n%d [style=dashed", forgetGate.ID, memoryCell.ID),            // forget gate
+		fmt.Sprintf(`n%d [label="%d\nbias=1\nlogistic"];`, forgetGate.ID, forgetGate.ID), // node label
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("WriteDOT: output does not contain %q:\n%s", want, dot)
+		}
+	}
+}
+
+func TestWriteDOTCollapsed(t *testing.T) {
+	testLookupTable := &automata.LookupTable{}
+	perceptron, err := automata.NewPerceptronNetwork(testLookupTable, []int{3, 4, 2})
+	if err != nil {
+		t.Fatalf("Failed to create NewPerceptronNetwork: %s", err.Error())
+	}
+	var buf bytes.Buffer
+	if err = perceptron.WriteDOT(&buf, automata.DOTOptions{CollapseLayers: true}); err != nil {
+		t.Fatalf("WriteDOT threw error: %s", err.Error())
+	}
+	dot := buf.String()
+	for _, want := range []string{
+		`l1 [shape=box, label="hidden 0\n4 neurons"];`,
+		`label="12"];`, // 3x4 connections between input and hidden
+		`label="8"];`,  // 4x2 connections between hidden and output
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("WriteDOT: output does not contain %q:\n%s", want, dot)
+		}
+	}
+	if strings.Contains(dot, "subgraph") {
+		t.Errorf("WriteDOT: collapsed output contains neuron clusters:\n%s", dot)
+	}
+}
@@ -0,0 +1,241 @@
+package automata
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// WriteGoSource writes Go source code for a function called 'funcName' in package 'pkg' which performs the same
+// computation as Activate on this network. The generated code only depends on the standard library. Weights and
+// biases are written as constants, so the function will not reflect any training done after it is generated.
+//
+// The state of every neuron and the gain of every gated connection is kept in package-level variables between
+// calls, starting from the current state of the network. This means recurrent networks like those made by NewLSTM
+// will produce the same sequence of outputs as calling Activate on the network. As a result, the generated
+// function is not safe to call concurrently.
+//
+// For example, with funcName "predict" and a network with 2 inputs and 1 output, the generated function is:
+//
+//   func predict(input [2]float64) [1]float64
+func (n *Network) WriteGoSource(w io.Writer, pkg, funcName string) error {
+	if !token.IsIdentifier(pkg) {
+		return fmt.Errorf("WriteGoSource: invalid package name %q", pkg)
+	}
+	if !token.IsIdentifier(funcName) {
+		return fmt.Errorf("WriteGoSource: invalid function name %q", funcName)
+	}
+	first, size := utf8.DecodeRuneInString(funcName)
+	prefix := string(unicode.ToLower(first)) + funcName[size:]
+
+	// Assign each neuron an index into the state/activation arrays, in activation order.
+	var neurons []*Neuron
+	indexes := make(map[NeuronID]int)
+	for _, layer := range n.layers() {
+		for _, neuron := range layer.List {
+			indexes[neuron.ID] = len(neurons)
+			neurons = append(neurons, neuron)
+		}
+	}
+	// Gains only change when the gater is activated, so only connections gated by a neuron in this network
+	// need a variable. All other gains are constants.
+	var gated []*Connection
+	gainIndexes := make(map[ConnID]int)
+	for _, neuron := range neurons {
+		for _, connID := range neuron.Gated {
+			if _, ok := gainIndexes[connID]; !ok {
+				gainIndexes[connID] = len(gated)
+				gated = append(gated, n.LookupTable().GetConnection(connID))
+			}
+		}
+	}
+
+	var err error
+	literal := func(v float64) string {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			err = fmt.Errorf("WriteGoSource: cannot write non-finite value %v", v)
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	gain := func(conn *Connection) string {
+		if i, ok := gainIndexes[conn.ID]; ok {
+			return fmt.Sprintf("g[%d]", i)
+		}
+		return literal(conn.Gain)
+	}
+
+	var body bytes.Buffer
+	squashers := make(map[string]string) // helper function name => body
+	var squasherNames []string
+	layers := n.layers()
+	layerEnd := len(n.Input.List) // the index after the last neuron in the current layer
+	for i, neuron := range neurons {
+		if i < len(n.Input.List) {
+			fmt.Fprintf(&body, "a[%d] = input[%d]\n", i, i)
+			continue
+		}
+		for i == layerEnd {
+			layers = layers[1:]
+			layerEnd += len(layers[0].List)
+		}
+		layer := layers[0]
+		// Eq. 15
+		fmt.Fprintf(&body, "s[%d] = %s*%s*s[%d] + %s\n", i, gain(neuron.Self), literal(neuron.Self.Weight), i, literal(neuron.Bias))
+		for _, connID := range neuron.Inputs {
+			conn := n.LookupTable().GetConnection(connID)
+			from, ok := indexes[conn.From.ID]
+			if !ok {
+				return fmt.Errorf("WriteGoSource: neuron %d has an input from neuron %d which is not in the network", neuron.ID, conn.From.ID)
+			}
+			fmt.Fprintf(&body, "s[%d] += a[%d] * %s * %s\n", i, from, literal(conn.Weight), gain(conn))
+		}
+		// Eq. 16
+		name, src, serr := squasherGoSource(neuron.Squash)
+		if serr != nil {
+			return fmt.Errorf("WriteGoSource: neuron %d: %s", neuron.ID, serr)
+		}
+		name = prefix + name
+		if _, ok := squashers[name]; !ok {
+			squashers[name] = src
+			squasherNames = append(squasherNames, name)
+		}
+		fmt.Fprintf(&body, "a[%d] = %s(s[%d])\n", i, name, i)
+		if layer.Activation != LayerActivationSoftmax {
+			for _, connID := range neuron.Gated {
+				fmt.Fprintf(&body, "g[%d] = a[%d]\n", gainIndexes[connID], i)
+			}
+			continue
+		}
+		if i < layerEnd-1 {
+			continue
+		}
+		// the whole layer has been activated, so replace the activations with the softmax of the states
+		start := layerEnd - len(layer.List)
+		if _, ok := squashers[prefix+"Softmax"]; !ok {
+			squashers[prefix+"Softmax"] = softmaxGoSource
+			squasherNames = append(squasherNames, prefix+"Softmax")
+		}
+		fmt.Fprintf(&body, "%sSoftmax(s[%d:%d], a[%d:%d])\n", prefix, start, layerEnd, start, layerEnd)
+		for j, softmaxNeuron := range layer.List {
+			for _, connID := range softmaxNeuron.Gated {
+				fmt.Fprintf(&body, "g[%d] = a[%d]\n", gainIndexes[connID], start+j)
+			}
+		}
+	}
+
+	var states, activations, gains []string
+	for _, neuron := range neurons {
+		states = append(states, literal(neuron.State))
+		activations = append(activations, literal(neuron.Activation))
+	}
+	for _, conn := range gated {
+		gains = append(gains, literal(conn.Gain))
+	}
+	var outputs []string
+	for i := len(neurons) - len(n.Output.List); i < len(neurons); i++ {
+		outputs = append(outputs, fmt.Sprintf("a[%d]", i))
+	}
+	if err != nil {
+		return err
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by automata. DO NOT EDIT.\n\npackage %s\n\n", pkg)
+	for _, name := range squasherNames {
+		if strings.Contains(squashers[name], "math.") {
+			src.WriteString("import \"math\"\n\n")
+			break
+		}
+	}
+	fmt.Fprintf(&src, "// State of the network which persists between calls to %s.\n", funcName)
+	fmt.Fprintf(&src, "var (\n%sState = [%d]float64{%s}\n", prefix, len(states), strings.Join(states, ", "))
+	fmt.Fprintf(&src, "%sActivation = [%d]float64{%s}\n", prefix, len(activations), strings.Join(activations, ", "))
+	fmt.Fprintf(&src, "%sGain = [%d]float64{%s}\n)\n\n", prefix, len(gains), strings.Join(gains, ", "))
+	fmt.Fprintf(&src, "// %s activates the network with the given input. It is not safe to call concurrently.\n", funcName)
+	fmt.Fprintf(&src, "func %s(input [%d]float64) [%d]float64 {\n", funcName, len(n.Input.List), len(outputs))
+	fmt.Fprintf(&src, "s, a, g := &%sState, &%sActivation, &%sGain\n", prefix, prefix, prefix)
+	if len(gated) == 0 {
+		src.WriteString("_ = g\n")
+	}
+	src.Write(body.Bytes())
+	fmt.Fprintf(&src, "return [%d]float64{%s}\n}\n", len(outputs), strings.Join(outputs, ", "))
+	for _, name := range squasherNames {
+		if name == prefix+"Softmax" {
+			fmt.Fprintf(&src, "\nfunc %s(s, a []float64) {\n%s\n}\n", name, squashers[name])
+			continue
+		}
+		fmt.Fprintf(&src, "\nfunc %s(x float64) float64 {\n%s\n}\n", name, squashers[name])
+	}
+
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return fmt.Errorf("WriteGoSource: generated invalid source: %s", err)
+	}
+	_, err = w.Write(formatted)
+	return err
+}
+
+// softmaxGoSource is the body of a Go function which sets the activations 'a' to the softmax of the states 's',
+// in the same way as Layer.Activate.
+const softmaxGoSource = `max := math.Inf(-1)
+for _, x := range s {
+max = math.Max(max, x)
+}
+var sum float64
+for i, x := range s {
+a[i] = math.Exp(x - max)
+sum += a[i]
+}
+for i := range a {
+a[i] /= sum
+}`
+
+// squasherGoSource returns a function name suffix and the body of a Go function which computes Squash(x, false)
+// for the given squasher. Squashers with parameters get a name suffix for each distinct parameter value.
+func squasherGoSource(s Squasher) (name, body string, err error) {
+	param := func(v float64) (string, string) {
+		literal := strconv.FormatFloat(v, 'g', -1, 64)
+		return strings.NewReplacer(".", "p", "-", "m", "+", "").Replace(literal), literal
+	}
+	switch s := s.(type) {
+	case *SquashLogistic:
+		return "Logistic", "return 1.0 / (1.0 + math.Exp(-x))", nil
+	case *SquashTanh:
+		return "Tanh", "return math.Tanh(x)", nil
+	case *SquashIdentity:
+		return "Identity", "return x", nil
+	case *SquashRelu:
+		return "Relu", "if x > 0 {\nreturn x\n}\nreturn 0", nil
+	case *SquashLeakyRelu:
+		suffix, slope := param(defaultFloat(s.Slope, 0.01))
+		return "LeakyRelu" + suffix, "if x > 0 {\nreturn x\n}\nreturn " + slope + " * x", nil
+	case *SquashElu:
+		suffix, alpha := param(defaultFloat(s.Alpha, 1))
+		return "Elu" + suffix, "if x > 0 {\nreturn x\n}\nreturn " + alpha + " * (math.Exp(x) - 1)", nil
+	case *SquashSelu:
+		return "Selu", fmt.Sprintf("if x > 0 {\nreturn %v * x\n}\nreturn %v * (%v * (math.Exp(x) - 1))",
+			seluScale, seluScale, seluAlpha), nil
+	case *SquashSoftplus:
+		return "Softplus", "return math.Max(x, 0) + math.Log1p(math.Exp(-math.Abs(x)))", nil
+	case *SquashSwish:
+		return "Swish", "return x * (1.0 / (1.0 + math.Exp(-x)))", nil
+	case *SquashGelu:
+		return "Gelu", "return x * (0.5 * (1 + math.Erf(x/math.Sqrt2)))", nil
+	case *SquashGaussian:
+		return "Gaussian", "return math.Exp(-x * x)", nil
+	case *SquashSinusoid:
+		return "Sinusoid", "return math.Sin(x)", nil
+	case *SquashHardTanh:
+		return "HardTanh", "return math.Max(-1, math.Min(1, x))", nil
+	case *SquashBentIdentity:
+		return "BentIdentity", "return (math.Sqrt(x*x+1)-1)/2 + x", nil
+	}
+	return "", "", fmt.Errorf("squasher type %T cannot be written as Go source", s)
+}
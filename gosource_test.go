@@ -0,0 +1,104 @@
+package automata_test
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/Kegsay/automata"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWriteGoSource(t *testing.T) {
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found, cannot run generated code")
+	}
+
+	testLookupTable := &automata.LookupTable{}
+	lstm := automata.NewLSTM(testLookupTable, 2, []int{3, 2}, 2)
+	squashers := []automata.Squasher{
+		&automata.SquashTanh{}, &automata.SquashRelu{}, &automata.SquashLeakyRelu{Slope: 0.2},
+		&automata.SquashLeakyRelu{}, &automata.SquashElu{Alpha: 0.5}, &automata.SquashSelu{},
+		&automata.SquashSoftplus{}, &automata.SquashSwish{}, &automata.SquashGelu{}, &automata.SquashGaussian{},
+		&automata.SquashSinusoid{}, &automata.SquashHardTanh{}, &automata.SquashBentIdentity{},
+	}
+	i := 0
+	for _, layer := range lstm.Hidden {
+		for _, neuron := range layer.List {
+			neuron.Squash = squashers[i%len(squashers)]
+			i++
+		}
+	}
+	lstm.Output.Activation = automata.LayerActivationSoftmax
+	lstm.Activate([]float64{1, 1}) // start from a non-zero state
+
+	var src bytes.Buffer
+	if err = lstm.WriteGoSource(&src, "main", "Predict"); err != nil {
+		t.Fatalf("WriteGoSource threw error: %s", err.Error())
+	}
+
+	inputs := [][]float64{{0, 0}, {0, 1}, {1, 1}, {1, 0}, {0.5, -0.5}}
+	var want, calls bytes.Buffer
+	for _, input := range inputs {
+		output, _ := lstm.Activate(input)
+		for _, out := range output {
+			fmt.Fprintln(&want, strconv.FormatFloat(out, 'g', -1, 64))
+		}
+		fmt.Fprintf(&calls, "print(Predict([2]float64{%v, %v}))\n", input[0], input[1])
+	}
+
+	dir, err := ioutil.TempDir("", "automata")
+	if err != nil {
+		t.Fatalf("TempDir threw error: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+	mainSrc := `package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+func print(output [2]float64) {
+	for _, out := range output {
+		fmt.Println(strconv.FormatFloat(out, 'g', -1, 64))
+	}
+}
+
+func main() {
+` + calls.String() + "}\n"
+	if err = ioutil.WriteFile(filepath.Join(dir, "network.go"), src.Bytes(), 0644); err != nil {
+		t.Fatalf("WriteFile threw error: %s", err.Error())
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte(mainSrc), 0644); err != nil {
+		t.Fatalf("WriteFile threw error: %s", err.Error())
+	}
+	cmd := exec.Command(goBin, "run", "main.go", "network.go")
+	cmd.Dir = dir
+	got, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("go run threw error: %s\n%s\n%s", err.Error(), got, src.String())
+	}
+	if strings.TrimSpace(string(got)) != strings.TrimSpace(want.String()) {
+		t.Errorf("generated code output mismatch: want\n%s\ngot\n%s", want.String(), got)
+	}
+}
+
+func TestWriteGoSourceBadName(t *testing.T) {
+	testLookupTable := &automata.LookupTable{}
+	perceptron, err := automata.NewPerceptronNetwork(testLookupTable, []int{2, 2, 1})
+	if err != nil {
+		t.Fatalf("Failed to create NewPerceptronNetwork: %s", err.Error())
+	}
+	if err = perceptron.WriteGoSource(ioutil.Discard, "main", "not valid"); err == nil {
+		t.Errorf("WriteGoSource: expected error for invalid function name, got nil")
+	}
+	if err = perceptron.WriteGoSource(ioutil.Discard, "main", "Ωpredict"); err != nil {
+		t.Errorf("WriteGoSource: want no error for a function name starting with a non-ASCII letter, got %s", err.Error())
+	}
+}
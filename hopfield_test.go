@@ -2,6 +2,7 @@ package automata_test
 
 import (
 	"github.com/Kegsay/automata"
+	"math/rand"
 	"strings"
 	"testing"
 )
@@ -52,7 +53,7 @@ const hopfieldImageFive = `
 1111111`
 
 func TestHopfieldImages(t *testing.T) {
-	testLookupTable := &automata.LookupTable{}
+	testLookupTable := &automata.LookupTable{Rand: rand.New(rand.NewSource(1))}
 	gridSize := 7
 	hopfield := automata.NewHopfieldNetwork(testLookupTable, gridSize*gridSize) // 7x7 grid
 
@@ -72,7 +73,7 @@ func TestHopfieldImages(t *testing.T) {
 		imageToTrainSet(t, hopfieldImageFive),
 	}
 
-	err := trainer.Train(trainingSet)
+	_, err := trainer.Train(trainingSet)
 	if err != nil {
 		t.Fatalf("trainer.Train threw error: %s", err.Error())
 	}
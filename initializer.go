@@ -0,0 +1,192 @@
+package automata
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Initializer picks the initial weights of the connections between two layers.
+type Initializer interface {
+	// Weights returns a fanIn x fanOut matrix of weights, indexed by the position of the neuron in the 'from'
+	// layer and then the position of the neuron in the 'to' layer. 'fanIn' is the size of the 'from' layer and
+	// 'fanOut' is the size of the 'to' layer.
+	Weights(fanIn, fanOut int, rng *rand.Rand) [][]float64
+}
+
+// Initialize sets the weight of every connection in the layer connection using the initializer. Self-connections,
+// which are used for memory in LSTM networks, keep their weight. The LookupTable's Rand is used as the source of
+// randomness if set.
+func (lc *LayerConnection) Initialize(init Initializer) {
+	lc.initialize(init, 0)
+}
+
+// initialize is Initialize, but only sets the weight of connections with an ID of at least 'first', so connections
+// which existed before this layer connection was made keep their weight.
+func (lc *LayerConnection) initialize(init Initializer, first ConnID) {
+	rng := lc.From.LookupTable.Rand
+	if rng == nil {
+		rng = rand.New(rand.NewSource(rand.Int63()))
+	}
+	weights := init.Weights(len(lc.From.List), len(lc.To.List), rng)
+	fromIndex := make(map[NeuronID]int, len(lc.From.List))
+	for i, n := range lc.From.List {
+		fromIndex[n.ID] = i
+	}
+	toIndex := make(map[NeuronID]int, len(lc.To.List))
+	for i, n := range lc.To.List {
+		toIndex[n.ID] = i
+	}
+	for _, conn := range lc.List {
+		if conn.From == conn.To || conn.ID < first {
+			continue
+		}
+		conn.Weight = weights[fromIndex[conn.From.ID]][toIndex[conn.To.ID]]
+	}
+}
+
+// GlorotUniformInitializer draws weights uniformly from [-limit, limit] where limit is sqrt(6 / (fanIn + fanOut)).
+// Also known as Xavier initialization, it suits logistic and tanh squashing functions.
+// See: http://proceedings.mlr.press/v9/glorot10a/glorot10a.pdf
+type GlorotUniformInitializer struct{}
+
+// Weights for the connections.
+func (i *GlorotUniformInitializer) Weights(fanIn, fanOut int, rng *rand.Rand) [][]float64 {
+	return uniformWeights(fanIn, fanOut, math.Sqrt(6/float64(fanIn+fanOut)), rng)
+}
+
+// GlorotNormalInitializer draws weights from a normal distribution with a mean of 0 and a standard deviation of
+// sqrt(2 / (fanIn + fanOut)).
+type GlorotNormalInitializer struct{}
+
+// Weights for the connections.
+func (i *GlorotNormalInitializer) Weights(fanIn, fanOut int, rng *rand.Rand) [][]float64 {
+	return normalWeights(fanIn, fanOut, math.Sqrt(2/float64(fanIn+fanOut)), rng)
+}
+
+// HeUniformInitializer draws weights uniformly from [-limit, limit] where limit is sqrt(6 / fanIn). It suits
+// the ReLU squashing function. See: https://arxiv.org/abs/1502.01852
+type HeUniformInitializer struct{}
+
+// Weights for the connections.
+func (i *HeUniformInitializer) Weights(fanIn, fanOut int, rng *rand.Rand) [][]float64 {
+	return uniformWeights(fanIn, fanOut, math.Sqrt(6/float64(fanIn)), rng)
+}
+
+// HeNormalInitializer draws weights from a normal distribution with a mean of 0 and a standard deviation of
+// sqrt(2 / fanIn).
+type HeNormalInitializer struct{}
+
+// Weights for the connections.
+func (i *HeNormalInitializer) Weights(fanIn, fanOut int, rng *rand.Rand) [][]float64 {
+	return normalWeights(fanIn, fanOut, math.Sqrt(2/float64(fanIn)), rng)
+}
+
+// LeCunUniformInitializer draws weights uniformly from [-limit, limit] where limit is sqrt(3 / fanIn).
+type LeCunUniformInitializer struct{}
+
+// Weights for the connections.
+func (i *LeCunUniformInitializer) Weights(fanIn, fanOut int, rng *rand.Rand) [][]float64 {
+	return uniformWeights(fanIn, fanOut, math.Sqrt(3/float64(fanIn)), rng)
+}
+
+// LeCunNormalInitializer draws weights from a normal distribution with a mean of 0 and a standard deviation of
+// sqrt(1 / fanIn).
+type LeCunNormalInitializer struct{}
+
+// Weights for the connections.
+func (i *LeCunNormalInitializer) Weights(fanIn, fanOut int, rng *rand.Rand) [][]float64 {
+	return normalWeights(fanIn, fanOut, math.Sqrt(1/float64(fanIn)), rng)
+}
+
+// OrthogonalInitializer uses a random orthogonal matrix multiplied by Gain as the weights. If the matrix is not
+// square, either its rows or its columns are orthonormal, whichever there are fewer of. See:
+// https://arxiv.org/abs/1312.6120
+type OrthogonalInitializer struct {
+	// Gain defaults to 1 if 0.
+	Gain float64
+}
+
+// Weights for the connections.
+func (i *OrthogonalInitializer) Weights(fanIn, fanOut int, rng *rand.Rand) [][]float64 {
+	// orthonormalise the vectors along the shorter side of the matrix, which are all as long as the longer side
+	count, length := fanIn, fanOut
+	if fanIn > fanOut {
+		count, length = fanOut, fanIn
+	}
+	vectors := normalWeights(count, length, 1, rng)
+	for v := range vectors {
+		// modified Gram-Schmidt: remove the components along every previous vector then normalise
+		for p := 0; p < v; p++ {
+			var dot float64
+			for k := range vectors[v] {
+				dot += vectors[v][k] * vectors[p][k]
+			}
+			for k := range vectors[v] {
+				vectors[v][k] -= dot * vectors[p][k]
+			}
+		}
+		var norm float64
+		for _, x := range vectors[v] {
+			norm += x * x
+		}
+		norm = math.Sqrt(norm)
+		for k := range vectors[v] {
+			vectors[v][k] /= norm
+		}
+	}
+
+	gain := defaultFloat(i.Gain, 1)
+	weights := make([][]float64, fanIn)
+	for r := range weights {
+		weights[r] = make([]float64, fanOut)
+		for c := range weights[r] {
+			if fanIn > fanOut {
+				weights[r][c] = gain * vectors[c][r]
+			} else {
+				weights[r][c] = gain * vectors[r][c]
+			}
+		}
+	}
+	return weights
+}
+
+// ConstantInitializer sets every weight to Weight.
+type ConstantInitializer struct {
+	Weight float64
+}
+
+// Weights for the connections.
+func (i *ConstantInitializer) Weights(fanIn, fanOut int, rng *rand.Rand) [][]float64 {
+	weights := make([][]float64, fanIn)
+	for r := range weights {
+		weights[r] = make([]float64, fanOut)
+		for c := range weights[r] {
+			weights[r][c] = i.Weight
+		}
+	}
+	return weights
+}
+
+// uniformWeights returns a rows x cols matrix of weights drawn uniformly from [-limit, limit].
+func uniformWeights(rows, cols int, limit float64, rng *rand.Rand) [][]float64 {
+	weights := make([][]float64, rows)
+	for r := range weights {
+		weights[r] = make([]float64, cols)
+		for c := range weights[r] {
+			weights[r][c] = (rng.Float64()*2 - 1) * limit
+		}
+	}
+	return weights
+}
+
+// normalWeights returns a rows x cols matrix of weights drawn from a normal distribution with a mean of 0.
+func normalWeights(rows, cols int, stddev float64, rng *rand.Rand) [][]float64 {
+	weights := make([][]float64, rows)
+	for r := range weights {
+		weights[r] = make([]float64, cols)
+		for c := range weights[r] {
+			weights[r][c] = rng.NormFloat64() * stddev
+		}
+	}
+	return weights
+}
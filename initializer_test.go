@@ -0,0 +1,111 @@
+package automata
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestInitializerDistributions(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	fanIn, fanOut := 200, 300
+	testCases := []struct {
+		name   string
+		init   Initializer
+		limit  float64 // the maximum absolute weight, or 0 if unbounded
+		stddev float64
+	}{
+		{"glorot uniform", &GlorotUniformInitializer{}, math.Sqrt(6.0 / 500), math.Sqrt(6.0/500) / math.Sqrt(3)},
+		{"glorot normal", &GlorotNormalInitializer{}, 0, math.Sqrt(2.0 / 500)},
+		{"he uniform", &HeUniformInitializer{}, math.Sqrt(6.0 / 200), math.Sqrt(2.0 / 200)},
+		{"he normal", &HeNormalInitializer{}, 0, math.Sqrt(2.0 / 200)},
+		{"lecun uniform", &LeCunUniformInitializer{}, math.Sqrt(3.0 / 200), math.Sqrt(1.0 / 200)},
+		{"lecun normal", &LeCunNormalInitializer{}, 0, math.Sqrt(1.0 / 200)},
+	}
+	for _, tc := range testCases {
+		weights := tc.init.Weights(fanIn, fanOut, rng)
+		if len(weights) != fanIn || len(weights[0]) != fanOut {
+			t.Fatalf("%s: want %dx%d weights, got %dx%d", tc.name, fanIn, fanOut, len(weights), len(weights[0]))
+		}
+		var sum, sumSquares float64
+		for _, row := range weights {
+			for _, w := range row {
+				if tc.limit > 0 && math.Abs(w) > tc.limit {
+					t.Fatalf("%s: weight %v is outside the limit %v", tc.name, w, tc.limit)
+				}
+				sum += w
+				sumSquares += w * w
+			}
+		}
+		n := float64(fanIn * fanOut)
+		stddev := math.Sqrt(sumSquares/n - (sum/n)*(sum/n))
+		if math.Abs(stddev-tc.stddev)/tc.stddev > 0.02 {
+			t.Errorf("%s: want standard deviation %v, got %v", tc.name, tc.stddev, stddev)
+		}
+	}
+}
+
+func TestOrthogonalInitializer(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, size := range [][2]int{{4, 4}, {3, 6}, {6, 3}} {
+		weights := (&OrthogonalInitializer{Gain: 2}).Weights(size[0], size[1], rng)
+		// the shorter side's vectors should be orthogonal with a length of Gain
+		count, length := size[0], size[1]
+		vector := func(v, k int) float64 { return weights[v][k] }
+		if size[0] > size[1] {
+			count, length = size[1], size[0]
+			vector = func(v, k int) float64 { return weights[k][v] }
+		}
+		for a := 0; a < count; a++ {
+			for b := 0; b < count; b++ {
+				var dot float64
+				for k := 0; k < length; k++ {
+					dot += vector(a, k) * vector(b, k)
+				}
+				want := 0.0
+				if a == b {
+					want = 4
+				}
+				if math.Abs(dot-want) > 1e-9 {
+					t.Errorf("%v: vectors %d and %d: want dot product %v, got %v", size, a, b, want, dot)
+				}
+			}
+		}
+	}
+}
+
+func TestLookupTableInitializer(t *testing.T) {
+	table := &LookupTable{Initializer: &ConstantInitializer{Weight: 0.5}}
+	lstm := NewLSTM(table, 1, []int{2}, 1)
+	selfConnections := 0
+	for _, conn := range table.Connections {
+		if conn.From == conn.To {
+			if conn.Weight == 1 {
+				selfConnections++
+			}
+			continue
+		}
+		if conn.Weight != 0.5 {
+			t.Errorf("connection %d: want weight 0.5, got %v", conn.ID, conn.Weight)
+		}
+	}
+	// the memory cells must keep their self-connections
+	if selfConnections != 2 {
+		t.Errorf("want 2 live self-connections, got %d", selfConnections)
+	}
+	if _, err := lstm.Activate([]float64{1}); err != nil {
+		t.Errorf("Activate threw error: %s", err.Error())
+	}
+
+	// projecting onto neurons which are already connected keeps the existing weights
+	inputLayer, outputLayer, extra := NewLayer(table, 2), NewLayer(table, 2), NewLayer(table, 1)
+	NewLayerConnection(&inputLayer, &outputLayer, LayerTypeAllToAll).List[0].Weight = 2
+	table.Initializer = &ConstantInitializer{Weight: 0.25}
+	wider := Layer{LookupTable: table, List: append(outputLayer.List[:2:2], extra.List...)}
+	for i, conn := range NewLayerConnection(&inputLayer, &wider, LayerTypeAllToAll).List {
+		want := []float64{2, 0.5, 0.25, 0.5, 0.5, 0.25}[i]
+		if conn.Weight != want {
+			t.Errorf("connection %d: want weight %v, got %v", conn.ID, want, conn.Weight)
+		}
+	}
+}
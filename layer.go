@@ -1,12 +1,31 @@
 package automata
 
-import "fmt"
+import (
+	"fmt"
+	"math"
+)
+
+// LayerActivation decides how the activations of the neurons in a layer are calculated.
+type LayerActivation int
+
+const (
+	// LayerActivationNeurons squashes the state of each neuron with its own Squasher. This is the default.
+	LayerActivationNeurons LayerActivation = iota
+	// LayerActivationSoftmax replaces the activations of the neurons with the softmax of their states, so the
+	// activations are all positive and add up to 1. This is intended for the output layer when classifying
+	// inputs into one of several classes, and should be trained with CategoricalCrossEntropyCost. The
+	// derivative of each neuron only accounts for its own state, so softmax should not be used on hidden layers.
+	LayerActivationSoftmax
+)
 
 // Layer represents a group of neurons which activate together.
 type Layer struct {
 	List        []*Neuron
 	ConnectedTo []LayerConnection
 	LookupTable *LookupTable
+	Activation  LayerActivation
+
+	independence layerIndependence // cached by concurrent
 }
 
 func NewLayer(table *LookupTable, size int) Layer {
@@ -26,9 +45,19 @@ func (l *Layer) Activate(inputs []float64) ([]float64, error) {
 
 	// Activate without an input
 	if inputs == nil {
-		for i := 0; i < len(l.List); i++ {
-			activation := l.List[i].Activate(nil)
-			activations = append(activations, activation)
+		activations = make([]float64, len(l.List))
+		activate := func(i int) {
+			activations[i] = l.List[i].Activate(nil)
+		}
+		if l.concurrent() {
+			parallel(l.LookupTable.Workers, len(l.List), activate)
+		} else {
+			for i := 0; i < len(l.List); i++ {
+				activate(i)
+			}
+		}
+		if l.Activation == LayerActivationSoftmax {
+			l.softmax(activations)
 		}
 	} else if len(inputs) != len(l.List) {
 		return nil, fmt.Errorf("input and layer size mismatch: cannot activate")
@@ -42,23 +71,116 @@ func (l *Layer) Activate(inputs []float64) ([]float64, error) {
 	return activations, nil
 }
 
+// softmax replaces the activations of the neurons in the layer with the softmax of their states.
+func (l *Layer) softmax(activations []float64) {
+	max := math.Inf(-1)
+	for _, neuron := range l.List {
+		max = math.Max(max, neuron.State)
+	}
+	var sum float64
+	for i, neuron := range l.List {
+		// subtract the largest state to avoid overflowing, which does not change the result
+		activations[i] = math.Exp(neuron.State - max)
+		sum += activations[i]
+	}
+	for i, neuron := range l.List {
+		activations[i] /= sum
+		neuron.Activation = activations[i]
+		neuron.Derivative = activations[i] * (1 - activations[i])
+		for _, connID := range neuron.Gated {
+			l.LookupTable.GetConnection(connID).Gain = neuron.Activation
+		}
+	}
+}
+
 // Propagate an error on all neurons in this layer.
 func (l *Layer) Propagate(rate float64, target []float64) error {
+	propagate := func(i int) {
+		l.List[i].Propagate(rate, nil)
+	}
 	if target != nil {
-		if len(target) != len(l.List) {
-			return fmt.Errorf("target and layer size mismatch: cannot propagate")
-		}
-		for i := len(l.List) - 1; i >= 0; i-- {
-			l.List[i].Propagate(rate, &target[i])
+		errs, err := l.targetErrors(target)
+		if err != nil {
+			return err
 		}
-	} else {
-		for i := len(l.List) - 1; i >= 0; i-- {
-			l.List[i].Propagate(rate, nil)
+		propagate = func(i int) {
+			l.List[i].propagateError(rate, errs[i])
 		}
 	}
+	if l.concurrent() {
+		l.LookupTable.reserve(l.List)
+		parallel(l.LookupTable.Workers, len(l.List), propagate)
+		return nil
+	}
+	for i := len(l.List) - 1; i >= 0; i-- {
+		propagate(i)
+	}
 	return nil
 }
 
+// targetErrors returns the error each neuron in this output layer should propagate for the given target. If the
+// cost function is Differentiable, the error comes from its gradient, otherwise it is the difference between the
+// target and the activation.
+func (l *Layer) targetErrors(target []float64) ([]float64, error) {
+	if len(target) != len(l.List) {
+		return nil, fmt.Errorf("target and layer size mismatch: cannot propagate")
+	}
+	cost := l.LookupTable.CostFunction
+	var weights []float64
+	if weighted, ok := cost.(*WeightedCost); ok {
+		if err := weighted.check(len(l.List)); err != nil {
+			return nil, fmt.Errorf("%s: cannot propagate", err)
+		}
+		cost, weights = weighted.Coster, weighted.Weights
+	}
+	if cost, ok := cost.(Differentiable); ok {
+		gradient := cost.Gradient(target, l.activations())
+		for i := range weights {
+			gradient[i] *= weights[i]
+		}
+		return l.outputErrors(gradient), nil
+	}
+	errs := make([]float64, len(l.List))
+	for i, neuron := range l.List {
+		// Eq. 10: output neurons get their error from the environment
+		errs[i] = target[i] - neuron.Activation
+		if weights != nil {
+			errs[i] *= weights[i]
+		}
+	}
+	return errs, nil
+}
+
+// activations returns the current activation of every neuron in the layer.
+func (l *Layer) activations() []float64 {
+	activations := make([]float64, len(l.List))
+	for i, neuron := range l.List {
+		activations[i] = neuron.Activation
+	}
+	return activations
+}
+
+// outputErrors converts the gradient of the cost with respect to each activation into the error each neuron should
+// propagate, which is the negative gradient with respect to its state.
+func (l *Layer) outputErrors(gradient []float64) []float64 {
+	errs := make([]float64, len(l.List))
+	if l.Activation == LayerActivationSoftmax {
+		// every activation depends on every state: d(a_i)/d(s_j) = a_i * (δij - a_j)
+		var weighted float64
+		for i, neuron := range l.List {
+			weighted += gradient[i] * neuron.Activation
+		}
+		for j, neuron := range l.List {
+			errs[j] = -neuron.Activation * (gradient[j] - weighted)
+		}
+		return errs
+	}
+	for i, neuron := range l.List {
+		errs[i] = -gradient[i] * neuron.Derivative
+	}
+	return errs
+}
+
 // Project a connection from this layer to another one.
 func (l *Layer) Project(toLayer *Layer, ltype LayerType) *LayerConnection {
 	if l.isConnected(toLayer) {
@@ -1,5 +1,7 @@
 package automata
 
+import "math/rand"
+
 // LookupTable stores mappings of:
 //  - Neuron IDs to Neurons
 //  - Connection IDs to Connections
@@ -19,17 +21,67 @@ package automata
 type LookupTable struct {
 	Neurons     []*Neuron
 	Connections []*Connection
+
+	// Rand is used to pick the initial weights and biases of new connections and neurons. Using the same seed
+	// produces the same network every time. If nil, the global math/rand functions are used.
+	Rand *rand.Rand
+	// Initializer picks the initial weights of the connections made whenever a layer is projected to another,
+	// including by the network constructors. If nil, weights are picked uniformly from [-0.1, 0.1].
+	Initializer Initializer
+
+	// Optimizer used to update weights and biases when neurons learn. If nil, plain stochastic gradient
+	// descent is used.
+	Optimizer Optimizer
+	// Workers is the number of goroutines used to activate and propagate the neurons of each layer concurrently,
+	// which can speed up networks with wide layers. Layers where neurons depend on each other, through connections
+	// within the layer, self-connections or gates, are always done one neuron at a time. The results are the same
+	// whatever the number of workers, but the Optimizer and Squashers must be safe to use concurrently, which the
+	// built-in ones are. If 0 or 1, every layer is done one neuron at a time.
+	Workers int
+
+	// CostFunction is the cost function being minimised by training. If it is Differentiable, its gradient is
+	// used as the error signal of output layers. The Trainer sets this to its CostFunction while training, and puts
+	// back the previous value when training returns.
+	CostFunction Coster
+	// WeightStates holds the optimizer state for each connection weight, indexed by ConnID.
+	WeightStates []OptimizerState
+	// BiasStates holds the optimizer state for each neuron bias, indexed by NeuronID.
+	BiasStates []OptimizerState
+
+	// AccumulateGradients makes neurons add their gradients to WeightGradients and BiasGradients when propagating,
+	// rather than updating their weights and biases immediately. The accumulated gradients are applied with
+	// ApplyGradients. This allows training in batches.
+	AccumulateGradients bool
+	// WeightGradients holds the accumulated gradient for each connection weight, indexed by ConnID.
+	WeightGradients []float64
+	// BiasGradients holds the accumulated gradient for each neuron bias, indexed by NeuronID.
+	BiasGradients []float64
+	// batchNeurons are the neurons which have accumulated gradients since they were last applied.
+	batchNeurons []NeuronID
+	inBatch      []bool
+	// topology counts changes to which neurons are connected or gated, so a cached plan of a network can tell
+	// when it is out of date.
+	topology uint64
+}
+
+// randFloat64 returns a random number in [0.0,1.0) from Rand, or the global source if Rand is nil.
+func (t *LookupTable) randFloat64() float64 {
+	if t.Rand == nil {
+		return rand.Float64()
+	}
+	return t.Rand.Float64()
 }
 
 // SetNeuron in the lookup table. Returns the ID for this neuron.
 func (t *LookupTable) SetNeuron(neuron *Neuron) NeuronID {
 	t.Neurons = append(t.Neurons, neuron)
+	t.topology++
 	return NeuronID(len(t.Neurons) - 1)
 }
 
 // GetNeuron from the lookup table. Returns nil if the ID does not exist in the table.
 func (t *LookupTable) GetNeuron(id NeuronID) *Neuron {
-	if int(id) > (len(t.Neurons) - 1) {
+	if id < 0 || int(id) > (len(t.Neurons)-1) {
 		return nil
 	}
 	return t.Neurons[id]
@@ -38,6 +90,7 @@ func (t *LookupTable) GetNeuron(id NeuronID) *Neuron {
 // SetConnection in the lookup table. Returns the ID for this connection.
 func (t *LookupTable) SetConnection(conn *Connection) ConnID {
 	t.Connections = append(t.Connections, conn)
+	t.topology++
 	return ConnID(len(t.Connections) - 1)
 }
 
@@ -49,13 +102,80 @@ func (t *LookupTable) SetConnectionWithID(id ConnID, conn *Connection) {
 		diff := int(id) - (len(t.Connections) - 1)
 		t.Connections = append(t.Connections, make([]*Connection, diff)...)
 	}
+	if t.Connections[id] != conn {
+		t.topology++
+	}
 	t.Connections[id] = conn
 }
 
+// weightDelta returns the amount to change the weight of the given connection by, using the table's Optimizer.
+func (t *LookupTable) weightDelta(id ConnID, rate, gradient float64) float64 {
+	if t.Optimizer == nil {
+		return rate * gradient
+	}
+	if int(id) > (len(t.WeightStates) - 1) {
+		diff := int(id) - (len(t.WeightStates) - 1)
+		t.WeightStates = append(t.WeightStates, make([]OptimizerState, diff)...)
+	}
+	return t.Optimizer.Update(&t.WeightStates[id], rate, gradient)
+}
+
+// biasDelta returns the amount to change the bias of the given neuron by, using the table's Optimizer.
+func (t *LookupTable) biasDelta(id NeuronID, rate, gradient float64) float64 {
+	if t.Optimizer == nil {
+		return rate * gradient
+	}
+	if int(id) > (len(t.BiasStates) - 1) {
+		diff := int(id) - (len(t.BiasStates) - 1)
+		t.BiasStates = append(t.BiasStates, make([]OptimizerState, diff)...)
+	}
+	return t.Optimizer.Update(&t.BiasStates[id], rate, gradient)
+}
+
 // GetConnection from the lookup table. Returns nil if the ID does not exist in the table.
 func (t *LookupTable) GetConnection(id ConnID) *Connection {
-	if int(id) > (len(t.Connections) - 1) {
+	if id < 0 || int(id) > (len(t.Connections)-1) {
 		return nil
 	}
 	return t.Connections[id]
 }
+
+// parameters is a copy of the learnable parameters in a LookupTable.
+type parameters struct {
+	weights []float64 // indexed by ConnID
+	biases  []float64 // indexed by NeuronID
+}
+
+// saveParameters returns a copy of the weight of every connection and the bias of every neuron in the table.
+func (t *LookupTable) saveParameters() parameters {
+	p := parameters{
+		weights: make([]float64, len(t.Connections)),
+		biases:  make([]float64, len(t.Neurons)),
+	}
+	for i, conn := range t.Connections {
+		if conn != nil {
+			p.weights[i] = conn.Weight
+		}
+	}
+	for i, neuron := range t.Neurons {
+		p.biases[i] = neuron.Bias
+	}
+	return p
+}
+
+// restoreParameters sets the weights and biases in the table to those previously returned by saveParameters.
+// Any optimizer state is discarded, as it relates to the replaced parameters.
+func (t *LookupTable) restoreParameters(p parameters) {
+	for i, weight := range p.weights {
+		if conn := t.GetConnection(ConnID(i)); conn != nil {
+			conn.Weight = weight
+		}
+	}
+	for i, bias := range p.biases {
+		if neuron := t.GetNeuron(NeuronID(i)); neuron != nil {
+			neuron.Bias = bias
+		}
+	}
+	t.WeightStates = nil
+	t.BiasStates = nil
+}
@@ -2,11 +2,12 @@ package automata_test
 
 import (
 	"github.com/Kegsay/automata"
+	"math/rand"
 	"testing"
 )
 
 func TestLSTM_ShortTerm(t *testing.T) {
-	testLookupTable := &automata.LookupTable{}
+	testLookupTable := &automata.LookupTable{Rand: rand.New(rand.NewSource(1))}
 	lstm := automata.NewLSTM(testLookupTable, 1, []int{6}, 1)
 	trainer := automata.Trainer{
 		Network:      lstm,
@@ -24,7 +25,7 @@ func TestLSTM_ShortTerm(t *testing.T) {
 		{[]float64{0}, []float64{0}},
 	}
 
-	if err := trainer.Train(trainSets); err != nil {
+	if _, err := trainer.Train(trainSets); err != nil {
 		t.Fatalf("trainer.Train threw error: %s", err.Error())
 	}
 
@@ -36,3 +37,49 @@ func TestLSTM_ShortTerm(t *testing.T) {
 	activateNetwork(t, lstm, []float64{0}, []float64{0})
 
 }
+
+func TestLSTM_GaterInfluences(t *testing.T) {
+	// Build the same LSTM after a neuron whose self-connection, connection 0, is either live or not. Connection 0
+	// is not part of the LSTM, so it must not change how the LSTM propagates.
+	newLSTM := func(live bool) (*automata.LookupTable, *automata.Network) {
+		table := &automata.LookupTable{Rand: rand.New(rand.NewSource(1))}
+		outside := automata.NewLayer(table, 1)
+		if live {
+			outside.Project(&outside, automata.LayerTypeAuto)
+			outside.Activate([]float64{1})
+		}
+		return table, automata.NewLSTM(table, 1, []int{2}, 1)
+	}
+	table, lstm := newLSTM(true)
+	_, want := newLSTM(false)
+
+	for _, layer := range lstm.Hidden {
+		for _, neuron := range layer.List {
+			for nid, influences := range neuron.TraceInfluences {
+				for _, connID := range influences {
+					if conn := table.GetConnection(connID); conn.To.ID != nid {
+						t.Errorf("neuron %d: influences on neuron %d include connection %d into neuron %d", neuron.ID, nid, connID, conn.To.ID)
+					}
+				}
+			}
+		}
+	}
+
+	for _, input := range []float64{1, 0, 1, 1} {
+		for _, network := range []*automata.Network{lstm, want} {
+			if _, err := network.Activate([]float64{input}); err != nil {
+				t.Fatalf("Activate threw error: %s", err.Error())
+			}
+			if err := network.Propagate(0.1, []float64{1 - input}); err != nil {
+				t.Fatalf("Propagate threw error: %s", err.Error())
+			}
+		}
+		for i, layer := range want.Hidden {
+			for j, neuron := range layer.List {
+				if got := lstm.Hidden[i].List[j].ErrorResponsibility; got != neuron.ErrorResponsibility {
+					t.Errorf("input %v: hidden layer %d neuron %d: want error responsibility %v, got %v", input, i, j, neuron.ErrorResponsibility, got)
+				}
+			}
+		}
+	}
+}
@@ -7,18 +7,48 @@ type Networker interface {
 	Propagate(rate float64, target []float64) error
 }
 
+// tableNetworker is a Networker which keeps its neurons and connections in a LookupTable, such as Network and
+// Hopfield. The Trainer needs the table to use optimizers, batches, early stopping and cross-validation.
+type tableNetworker interface {
+	Networker
+	LookupTable() *LookupTable
+}
+
+// networkTable returns the LookupTable of the network, or nil if it does not have one.
+func networkTable(network Networker) *LookupTable {
+	if n, ok := network.(tableNetworker); ok {
+		return n.LookupTable()
+	}
+	return nil
+}
+
 // Network represents an arbitrary artificial neural network.
 type Network struct {
 	Input  *Layer
 	Hidden []Layer
 	Output *Layer
+
+	dense *denseNetwork // the cached dense form of the network, see denseNetwork
 }
 
 // Activate the network with the given neuron, feeding forward to produce an output.
+//
+// Networks where each layer is only connected all-to-all to the next, without gates or self-connections, such as
+// those made by NewPerceptronNetwork, are activated and propagated layer by layer as matrix-vector products rather
+// than neuron by neuron. This produces the same results faster, but skips updating the traces of each neuron, so
+// such networks should be activated and propagated through the Network rather than its layers or neurons.
 func (n *Network) Activate(input []float64) ([]float64, error) {
+	if dense := n.denseNetwork(); dense != nil {
+		return dense.activate(input)
+	}
+	return n.activateNeurons(input)
+}
+
+// activateNeurons activates the network neuron by neuron.
+func (n *Network) activateNeurons(input []float64) ([]float64, error) {
 	n.Input.Activate(input)
-	for _, layer := range n.Hidden {
-		layer.Activate(nil)
+	for i := range n.Hidden {
+		n.Hidden[i].Activate(nil)
 	}
 	return n.Output.Activate(nil)
 }
@@ -30,6 +60,14 @@ func (n *Network) Activate(input []float64) ([]float64, error) {
 // as the error continues to propagate, and can be exacerbated depending on the squashing function used,
 // making earlier layers difficult to train.
 func (n *Network) Propagate(rate float64, target []float64) error {
+	if dense := n.denseNetwork(); dense != nil {
+		return dense.propagate(rate, target)
+	}
+	return n.propagateNeurons(rate, target)
+}
+
+// propagateNeurons propagates the error through the network neuron by neuron.
+func (n *Network) propagateNeurons(rate float64, target []float64) error {
 	err := n.Output.Propagate(rate, target)
 	if err != nil {
 		return err
@@ -43,6 +81,11 @@ func (n *Network) Propagate(rate float64, target []float64) error {
 	return nil
 }
 
+// LookupTable returns the table which holds the neurons and connections in this network.
+func (n *Network) LookupTable() *LookupTable {
+	return n.Input.LookupTable
+}
+
 func (n *Network) ProjectLayer(layer *Layer, ltype LayerType) {
 	n.Output.Project(layer, ltype)
 }
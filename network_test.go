@@ -3,6 +3,7 @@ package automata_test
 import (
 	"github.com/Kegsay/automata"
 	"math"
+	"math/rand"
 	"testing"
 )
 
@@ -26,7 +27,7 @@ func TestANDGate(t *testing.T) {
 		Iterations:   1000,
 		CostFunction: &automata.MeanSquaredErrorCost{},
 	}
-	err := trainer.Train([]automata.TrainSet{
+	_, err := trainer.Train([]automata.TrainSet{
 		{[]float64{0, 0}, []float64{0}},
 		{[]float64{0, 1}, []float64{0}},
 		{[]float64{1, 0}, []float64{0}},
@@ -63,7 +64,7 @@ func TestORGate(t *testing.T) {
 		Iterations:   1000,
 		CostFunction: &automata.MeanSquaredErrorCost{},
 	}
-	err := trainer.Train([]automata.TrainSet{
+	_, err := trainer.Train([]automata.TrainSet{
 		{[]float64{0, 0}, []float64{0}},
 		{[]float64{0, 1}, []float64{1}},
 		{[]float64{1, 0}, []float64{1}},
@@ -100,7 +101,7 @@ func TestNOTGate(t *testing.T) {
 		Iterations:   1000,
 		CostFunction: &automata.MeanSquaredErrorCost{},
 	}
-	err := trainer.Train([]automata.TrainSet{
+	_, err := trainer.Train([]automata.TrainSet{
 		{[]float64{0}, []float64{1}},
 		{[]float64{1}, []float64{0}},
 	})
@@ -128,3 +129,35 @@ func activateNetwork(t *testing.T, network *automata.Network, input, desiredOutp
 func round(in float64) float64 {
 	return math.Floor(in + 0.5)
 }
+
+func TestSeededLookupTable(t *testing.T) {
+	constructors := map[string]func(table *automata.LookupTable){
+		"perceptron": func(table *automata.LookupTable) {
+			if _, err := automata.NewPerceptronNetwork(table, []int{2, 3, 1}); err != nil {
+				t.Fatalf("Failed to create NewPerceptronNetwork: %s", err.Error())
+			}
+		},
+		"lstm": func(table *automata.LookupTable) {
+			automata.NewLSTM(table, 1, []int{3}, 1)
+		},
+		"hopfield": func(table *automata.LookupTable) {
+			automata.NewHopfieldNetwork(table, 4)
+		},
+	}
+	for name, construct := range constructors {
+		a := &automata.LookupTable{Rand: rand.New(rand.NewSource(42))}
+		b := &automata.LookupTable{Rand: rand.New(rand.NewSource(42))}
+		construct(a)
+		construct(b)
+		for i := range a.Neurons {
+			if a.Neurons[i].Bias != b.Neurons[i].Bias {
+				t.Errorf("%s: neuron %d: want bias %v, got %v", name, i, a.Neurons[i].Bias, b.Neurons[i].Bias)
+			}
+		}
+		for i := range a.Connections {
+			if a.Connections[i].Weight != b.Connections[i].Weight {
+				t.Errorf("%s: connection %d: want weight %v, got %v", name, i, a.Connections[i].Weight, b.Connections[i].Weight)
+			}
+		}
+	}
+}
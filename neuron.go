@@ -1,9 +1,5 @@
 package automata
 
-import (
-	"math/rand"
-)
-
 type NeuronID int64
 
 // Neuron represents a base unit of work in a neural network.
@@ -31,12 +27,14 @@ type Neuron struct {
 	TraceInfluences  map[NeuronID][]ConnID
 
 	LookupTable *LookupTable
+
+	extended []NeuronID // the keys of TraceExtended in order, see extendedNeurons
 }
 
 func NewNeuron(table *LookupTable) *Neuron {
 	n := Neuron{
 		Squash:          &SquashLogistic{},
-		Bias:            (rand.Float64() / 2) - 0.25, // Bias range from -0.25 ~ 0.25 initially
+		Bias:            (table.randFloat64() / 2) - 0.25, // Bias range from -0.25 ~ 0.25 initially
 		TraceExtended:   make(map[NeuronID]map[ConnID]float64),
 		TraceInfluences: make(map[NeuronID][]ConnID),
 		LookupTable:     table,
@@ -142,7 +140,7 @@ func (n *Neuron) Propagate(rate float64, target *float64) {
 		n.ErrorProjected = n.Derivative * accumulatedError
 
 		accumulatedError = 0
-		for nid := range n.TraceExtended {
+		for _, nid := range n.extendedNeurons() {
 			var influence float64
 			neuron := n.LookupTable.GetNeuron(nid) // gated neuron
 			if neuron.Self.Gater == n {
@@ -164,10 +162,19 @@ func (n *Neuron) Propagate(rate float64, target *float64) {
 	n.learn(rate)
 }
 
+// propagateError propagates an error from the environment through this output neuron, where 'err' is the
+// negative of the gradient of the cost with respect to the state of this neuron.
+func (n *Neuron) propagateError(rate, err float64) {
+	n.ErrorResponsibility = err
+	n.ErrorProjected = err
+	n.learn(rate)
+}
+
 func (n *Neuron) Project(targetNeuron *Neuron, weight *float64) *Connection {
 	if targetNeuron == n {
 		// fmt.Println("PROJECT: self", n.ID)
 		n.Self.Weight = 1 // make connection live (1 = connected)
+		n.LookupTable.topology++
 		return n.Self
 	}
 
@@ -197,6 +204,7 @@ func (n *Neuron) Project(targetNeuron *Neuron, weight *float64) *Connection {
 
 func (n *Neuron) Gate(conn *Connection) {
 	n.Gated = append(n.Gated, conn.ID)
+	n.LookupTable.topology++
 	if _, ok := n.TraceExtended[conn.To.ID]; !ok {
 		n.Neighbours = append(n.Neighbours, conn.To.ID)
 		n.TraceExtended[conn.To.ID] = make(map[ConnID]float64)
@@ -206,12 +214,7 @@ func (n *Neuron) Gate(conn *Connection) {
 		}
 	}
 
-	if n.TraceInfluences[conn.To.ID] == nil {
-		n.TraceInfluences[conn.To.ID] = make([]ConnID, 1)
-	}
-	arr := n.TraceInfluences[conn.To.ID]
-	arr = append(arr, conn.ID)
-	n.TraceInfluences[conn.To.ID] = arr
+	n.TraceInfluences[conn.To.ID] = append(n.TraceInfluences[conn.To.ID], conn.ID)
 	conn.Gater = n
 }
 
@@ -230,22 +233,40 @@ func (n *Neuron) ConnectionForNeuron(target *Neuron) *Connection {
 	return n.getConnectionForNeuron(n.Gated, target)
 }
 
-// learn by adjusting weights.
+// learn by adjusting weights, or by accumulating gradients if the LookupTable is accumulating gradients.
 func (n *Neuron) learn(rate float64) {
+	if n.LookupTable.AccumulateGradients {
+		n.LookupTable.accumulate(n)
+		return
+	}
 	for _, connID := range n.Inputs {
 		conn := n.LookupTable.GetConnection(connID)
-		// Eq. 24
-		gradient := n.ErrorProjected * n.getTraceEligibility(conn.ID)
-		for neuronID := range n.TraceExtended {
-			neuron := n.LookupTable.GetNeuron(neuronID)
-			gradient += neuron.ErrorResponsibility * n.TraceExtended[neuronID][conn.ID]
-		}
-
-		conn.Weight += rate * gradient
+		conn.Weight += n.LookupTable.weightDelta(connID, rate, n.gradient(conn))
 		n.LookupTable.SetConnectionWithID(connID, conn)
 	}
 
-	n.Bias += rate * n.ErrorResponsibility
+	n.Bias += n.LookupTable.biasDelta(n.ID, rate, n.ErrorResponsibility)
+}
+
+// gradient returns the gradient of the given input connection's weight, based on the last propagated error.
+func (n *Neuron) gradient(conn *Connection) float64 {
+	// Eq. 24
+	gradient := n.ErrorProjected * n.getTraceEligibility(conn.ID)
+	for _, neuronID := range n.extendedNeurons() {
+		neuron := n.LookupTable.GetNeuron(neuronID)
+		gradient += neuron.ErrorResponsibility * n.TraceExtended[neuronID][conn.ID]
+	}
+	return gradient
+}
+
+// extendedNeurons returns the IDs of the neurons this neuron gates connections to in ascending order, so errors
+// and gradients summed over them are always added up in the same order. Gated neurons are only ever added, so the
+// IDs are sorted again whenever there are more of them.
+func (n *Neuron) extendedNeurons() []NeuronID {
+	if len(n.extended) != len(n.TraceExtended) {
+		n.extended = sortedNeuronIDs(n.TraceExtended)
+	}
+	return n.extended
 }
 
 func (n *Neuron) getConnectionForNeuron(cidList []ConnID, target *Neuron) *Connection {
@@ -0,0 +1,119 @@
+package automata
+
+import "math"
+
+// Optimizer decides how weights and biases are updated when a neuron learns.
+//
+// Optimizers are given the gradient for a single parameter. This is the direction in which the parameter should
+// move to reduce the error (Eq. 24 in the paper), so a positive gradient means the parameter should increase.
+// Any state the optimizer needs to keep between updates is stored in an OptimizerState which is unique to each
+// parameter. These are kept in the LookupTable, keyed by ConnID for weights and NeuronID for biases.
+type Optimizer interface {
+	// Update returns the amount to add to a parameter with the given gradient and learning rate.
+	Update(state *OptimizerState, rate, gradient float64) float64
+}
+
+// OptimizerState is the per-parameter state for an Optimizer. How each field is used depends on the optimizer.
+type OptimizerState struct {
+	// Velocity is the running (first moment) average of previous updates or gradients.
+	Velocity float64
+	// Accumulator is the running sum or average of squared gradients (the second moment).
+	Accumulator float64
+	// Steps is the number of updates made so far.
+	Steps int
+}
+
+// SGDOptimizer implements plain stochastic gradient descent, which is the default if no Optimizer is set.
+type SGDOptimizer struct{}
+
+// Update a parameter.
+func (o *SGDOptimizer) Update(state *OptimizerState, rate, gradient float64) float64 {
+	return rate * gradient
+}
+
+// MomentumOptimizer implements classical momentum, which accelerates updates in directions with a consistent
+// gradient and dampens oscillations.
+type MomentumOptimizer struct {
+	// Momentum is the fraction of the previous update to add to this update. Typically 0.9.
+	Momentum float64
+}
+
+// Update a parameter.
+func (o *MomentumOptimizer) Update(state *OptimizerState, rate, gradient float64) float64 {
+	state.Velocity = o.Momentum*state.Velocity + rate*gradient
+	return state.Velocity
+}
+
+// NesterovOptimizer implements Nesterov accelerated gradient, a variant of momentum which corrects the update
+// by looking ahead to where the momentum will take the parameter.
+type NesterovOptimizer struct {
+	// Momentum is the fraction of the previous update to add to this update. Typically 0.9.
+	Momentum float64
+}
+
+// Update a parameter.
+func (o *NesterovOptimizer) Update(state *OptimizerState, rate, gradient float64) float64 {
+	prev := state.Velocity
+	state.Velocity = o.Momentum*state.Velocity + rate*gradient
+	return -o.Momentum*prev + (1+o.Momentum)*state.Velocity
+}
+
+// AdaGradOptimizer implements AdaGrad, which scales the learning rate for each parameter by the history of its
+// gradients. Parameters with large gradients get smaller updates.
+type AdaGradOptimizer struct {
+	// Epsilon avoids division by zero. Defaults to 1e-8 if 0.
+	Epsilon float64
+}
+
+// Update a parameter.
+func (o *AdaGradOptimizer) Update(state *OptimizerState, rate, gradient float64) float64 {
+	state.Accumulator += gradient * gradient
+	return rate * gradient / (math.Sqrt(state.Accumulator) + defaultFloat(o.Epsilon, 1e-8))
+}
+
+// RMSPropOptimizer implements RMSProp, which scales the learning rate for each parameter by a moving average of
+// its recent squared gradients.
+type RMSPropOptimizer struct {
+	// Decay is the decay rate of the moving average. Defaults to 0.9 if 0.
+	Decay float64
+	// Epsilon avoids division by zero. Defaults to 1e-8 if 0.
+	Epsilon float64
+}
+
+// Update a parameter.
+func (o *RMSPropOptimizer) Update(state *OptimizerState, rate, gradient float64) float64 {
+	decay := defaultFloat(o.Decay, 0.9)
+	state.Accumulator = decay*state.Accumulator + (1-decay)*gradient*gradient
+	return rate * gradient / (math.Sqrt(state.Accumulator) + defaultFloat(o.Epsilon, 1e-8))
+}
+
+// AdamOptimizer implements Adam, which combines momentum with RMSProp-style scaling, correcting both moving
+// averages for their bias towards zero in early steps.
+// See: https://arxiv.org/abs/1412.6980
+type AdamOptimizer struct {
+	// Beta1 is the decay rate of the first moment. Defaults to 0.9 if 0.
+	Beta1 float64
+	// Beta2 is the decay rate of the second moment. Defaults to 0.999 if 0.
+	Beta2 float64
+	// Epsilon avoids division by zero. Defaults to 1e-8 if 0.
+	Epsilon float64
+}
+
+// Update a parameter.
+func (o *AdamOptimizer) Update(state *OptimizerState, rate, gradient float64) float64 {
+	beta1 := defaultFloat(o.Beta1, 0.9)
+	beta2 := defaultFloat(o.Beta2, 0.999)
+	state.Steps++
+	state.Velocity = beta1*state.Velocity + (1-beta1)*gradient
+	state.Accumulator = beta2*state.Accumulator + (1-beta2)*gradient*gradient
+	m := state.Velocity / (1 - math.Pow(beta1, float64(state.Steps)))
+	v := state.Accumulator / (1 - math.Pow(beta2, float64(state.Steps)))
+	return rate * m / (math.Sqrt(v) + defaultFloat(o.Epsilon, 1e-8))
+}
+
+func defaultFloat(val, def float64) float64 {
+	if val == 0 {
+		return def
+	}
+	return val
+}
@@ -0,0 +1,78 @@
+package automata
+
+import (
+	"math"
+	"testing"
+)
+
+func TestOptimizerUpdates(t *testing.T) {
+	testCases := []struct {
+		name      string
+		optimizer Optimizer
+		want      []float64 // updates for gradients of 1, 1, -1 with a learning rate of 0.1
+	}{
+		{"sgd", &SGDOptimizer{}, []float64{0.1, 0.1, -0.1}},
+		{"momentum", &MomentumOptimizer{Momentum: 0.5}, []float64{0.1, 0.15, -0.025}},
+		{"nesterov", &NesterovOptimizer{Momentum: 0.5}, []float64{0.15, 0.175, -0.1125}},
+		{"adagrad", &AdaGradOptimizer{}, []float64{0.1, 0.1 / math.Sqrt(2), -0.1 / math.Sqrt(3)}},
+		{"rmsprop", &RMSPropOptimizer{Decay: 0.5}, []float64{0.1 / math.Sqrt(0.5), 0.1 / math.Sqrt(0.75), -0.1 / math.Sqrt(0.875)}},
+		{"adam", &AdamOptimizer{}, []float64{0.1, 0.1, 0.1 * 0.071 / 0.271}},
+	}
+	for _, tc := range testCases {
+		var state OptimizerState
+		for i, gradient := range []float64{1, 1, -1} {
+			got := tc.optimizer.Update(&state, 0.1, gradient)
+			if math.Abs(got-tc.want[i]) > 1e-6 {
+				t.Errorf("%s: update %d: want %v, got %v", tc.name, i, tc.want[i], got)
+			}
+		}
+	}
+}
+
+func TestTrainerOptimizer(t *testing.T) {
+	for _, optimizer := range []Optimizer{
+		&MomentumOptimizer{Momentum: 0.9},
+		&NesterovOptimizer{Momentum: 0.9},
+		&AdaGradOptimizer{},
+		&RMSPropOptimizer{},
+		&AdamOptimizer{},
+	} {
+		table := &LookupTable{}
+		inputLayer := NewLayer(table, 2)
+		outputLayer := NewLayer(table, 1)
+		inputLayer.Project(&outputLayer, LayerTypeAuto)
+		network := Network{
+			Input:  &inputLayer,
+			Output: &outputLayer,
+		}
+		trainer := Trainer{
+			Network:      &network,
+			MaxErrorRate: 0.001,
+			LearnRate:    0.1,
+			Iterations:   2000,
+			CostFunction: &MeanSquaredErrorCost{},
+			Optimizer:    optimizer,
+		}
+		sets := []TrainSet{
+			{[]float64{0, 0}, []float64{0}},
+			{[]float64{0, 1}, []float64{1}},
+			{[]float64{1, 0}, []float64{1}},
+			{[]float64{1, 1}, []float64{1}},
+		}
+		if _, err := trainer.Train(sets); err != nil {
+			t.Fatalf("%T: trainer.Train threw error: %s", optimizer, err.Error())
+		}
+		if table.Optimizer != optimizer {
+			t.Errorf("%T: Trainer did not set the optimizer on the LookupTable", optimizer)
+		}
+		if len(table.WeightStates) == 0 || len(table.BiasStates) == 0 {
+			t.Errorf("%T: no optimizer state was kept", optimizer)
+		}
+		for _, set := range sets {
+			output, _ := network.Activate(set.Input)
+			if math.Floor(output[0]+0.5) != set.Output[0] {
+				t.Errorf("%T: OR(%v): want %v, got %v", optimizer, set.Input, set.Output[0], output[0])
+			}
+		}
+	}
+}
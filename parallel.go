@@ -0,0 +1,138 @@
+package automata
+
+import "sync"
+
+// layerIndependence caches whether the neurons in a layer depend on each other, until the layer or the topology
+// of its LookupTable changes.
+type layerIndependence struct {
+	table       *LookupTable
+	topology    uint64
+	list        []*Neuron
+	independent bool
+}
+
+// concurrent returns true if the neurons in the layer should be activated and propagated concurrently, which
+// needs more than one worker and neurons which do not depend on each other.
+func (l *Layer) concurrent() bool {
+	table := l.LookupTable
+	if table.Workers < 2 || len(l.List) < 2 {
+		return false
+	}
+	c := &l.independence
+	if c.table != table || c.topology != table.topology || len(c.list) != len(l.List) || &c.list[0] != &l.List[0] {
+		*c = layerIndependence{
+			table:       table,
+			topology:    table.topology,
+			list:        l.List,
+			independent: l.independent(),
+		}
+	}
+	return c.independent
+}
+
+// independent returns true if no neuron in the layer reads anything another neuron in the layer writes when
+// activating or propagating. This rules out connections within the layer, including self-connections, neurons
+// which gate connections to or from the layer, and neurons which gate the same connection.
+func (l *Layer) independent() bool {
+	inLayer := make(map[*Neuron]bool, len(l.List))
+	for _, neuron := range l.List {
+		inLayer[neuron] = true
+	}
+	gated := make(map[ConnID]bool)
+	for _, neuron := range l.List {
+		if neuron.Self.Weight != 0 {
+			return false
+		}
+		for _, connID := range neuron.Inputs {
+			if inLayer[l.LookupTable.GetConnection(connID).From] {
+				return false
+			}
+		}
+		for _, connID := range neuron.Gated {
+			conn := l.LookupTable.GetConnection(connID)
+			if inLayer[conn.From] || inLayer[conn.To] || gated[connID] {
+				return false
+			}
+			gated[connID] = true
+		}
+		// gaters also read the activations of the neurons feeding the connections they gate
+		for _, connIDs := range neuron.TraceInfluences {
+			for _, connID := range connIDs {
+				if inLayer[l.LookupTable.GetConnection(connID).From] {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// parallel calls f with every index from 0 to count, split into contiguous chunks between the given number of
+// goroutines, and waits for them all to finish.
+func parallel(workers, count int, f func(i int)) {
+	if workers > count {
+		workers = count
+	}
+	size := (count + workers - 1) / workers
+	var wg sync.WaitGroup
+	for start := 0; start < count; start += size {
+		end := start + size
+		if end > count {
+			end = count
+		}
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			for i := start; i < end; i++ {
+				f(i)
+			}
+		}(start, end)
+	}
+	wg.Wait()
+}
+
+// reserve grows the optimizer state and accumulated gradients in the table to fit the given neurons and their
+// input connections, and adds the neurons to the batch if gradients are being accumulated, so the neurons can learn
+// concurrently without appending to shared slices. Neurons are added to the batch in reverse order, which is the
+// order they propagate in one at a time.
+func (t *LookupTable) reserve(neurons []*Neuron) {
+	if t.Optimizer == nil && !t.AccumulateGradients {
+		return
+	}
+	maxConn, maxNeuron := ConnID(-1), NeuronID(-1)
+	for _, neuron := range neurons {
+		for _, connID := range neuron.Inputs {
+			if connID > maxConn {
+				maxConn = connID
+			}
+		}
+		if neuron.ID > maxNeuron {
+			maxNeuron = neuron.ID
+		}
+	}
+	conns, count := int(maxConn)+1, int(maxNeuron)+1
+	if t.Optimizer != nil {
+		if len(t.WeightStates) < conns {
+			t.WeightStates = append(t.WeightStates, make([]OptimizerState, conns-len(t.WeightStates))...)
+		}
+		if len(t.BiasStates) < count {
+			t.BiasStates = append(t.BiasStates, make([]OptimizerState, count-len(t.BiasStates))...)
+		}
+	}
+	if !t.AccumulateGradients {
+		return
+	}
+	if len(t.WeightGradients) < conns {
+		t.WeightGradients = append(t.WeightGradients, make([]float64, conns-len(t.WeightGradients))...)
+	}
+	if len(t.BiasGradients) < count {
+		t.inBatch = append(t.inBatch, make([]bool, count-len(t.BiasGradients))...)
+		t.BiasGradients = append(t.BiasGradients, make([]float64, count-len(t.BiasGradients))...)
+	}
+	for i := len(neurons) - 1; i >= 0; i-- {
+		if id := neurons[i].ID; !t.inBatch[id] {
+			t.inBatch[id] = true
+			t.batchNeurons = append(t.batchNeurons, id)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package automata
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestLayerIndependent(t *testing.T) {
+	lstm := NewLSTM(&LookupTable{}, 2, []int{3}, 2)
+	// the input, forget and output gates only gate connections between other layers, but memory cells connect
+	// to themselves
+	want := []bool{true, true, false, true}
+	for i := range lstm.Hidden {
+		if got := lstm.Hidden[i].independent(); got != want[i] {
+			t.Errorf("LSTM hidden layer %d: want independent %v, got %v", i, want[i], got)
+		}
+	}
+	if !lstm.Output.independent() {
+		t.Errorf("want LSTM output layer to be independent")
+	}
+
+	table := &LookupTable{}
+	layer := NewLayer(table, 3)
+	layer.Project(&layer, LayerTypeAllToElse)
+	if layer.independent() {
+		t.Errorf("want a layer connected to itself not to be independent")
+	}
+}
+
+func TestParallelLayers(t *testing.T) {
+	testCases := []struct {
+		name      string
+		network   func(table *LookupTable) *Network
+		optimizer Optimizer
+		batch     bool
+	}{
+		{"lstm", func(table *LookupTable) *Network { return NewLSTM(table, 2, []int{6, 4}, 3) }, &AdamOptimizer{}, false},
+		{"lstm batch", func(table *LookupTable) *Network { return NewLSTM(table, 2, []int{6}, 3) }, &MomentumOptimizer{}, true},
+		{"perceptron", func(table *LookupTable) *Network {
+			network, _ := NewPerceptronNetwork(table, []int{2, 7, 5, 3})
+			return network
+		}, &RMSPropOptimizer{}, true},
+	}
+	sets := []TrainSet{
+		{[]float64{0, 1}, []float64{1, 0, 0}},
+		{[]float64{1, 0.5}, []float64{0, 1, 0}},
+		{[]float64{1, 1}, []float64{0, 0, 1}},
+	}
+	for _, tc := range testCases {
+		var networks []*Network
+		for _, workers := range []int{0, 4} {
+			table := &LookupTable{
+				Rand:                rand.New(rand.NewSource(1)),
+				Optimizer:           tc.optimizer,
+				AccumulateGradients: tc.batch,
+				CostFunction:        &CrossEntropyCost{},
+				Workers:             workers,
+			}
+			networks = append(networks, tc.network(table))
+		}
+		sequential, concurrent := networks[0], networks[1]
+		for iter := 0; iter < 5; iter++ {
+			for _, set := range sets {
+				want, _ := sequential.Activate(set.Input)
+				got, _ := concurrent.Activate(set.Input)
+				if !reflect.DeepEqual(got, want) {
+					t.Fatalf("%s: want output %v, got %v", tc.name, want, got)
+				}
+				sequential.Propagate(0.3, set.Output)
+				concurrent.Propagate(0.3, set.Output)
+			}
+			if tc.batch {
+				sequential.LookupTable().ApplyGradients(0.3, len(sets))
+				concurrent.LookupTable().ApplyGradients(0.3, len(sets))
+			}
+		}
+		want, got := sequential.LookupTable().saveParameters(), concurrent.LookupTable().saveParameters()
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: want parameters %v, got %v", tc.name, want, got)
+		}
+	}
+}
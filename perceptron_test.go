@@ -8,8 +8,7 @@ import (
 )
 
 func TestPerceptronXOR(t *testing.T) {
-	testLookupTable := &automata.LookupTable{}
-	rand.Seed(1) // consistent seed for consistent errors!
+	testLookupTable := &automata.LookupTable{Rand: rand.New(rand.NewSource(1))} // consistent seed for consistent errors!
 	perceptron, err := automata.NewPerceptronNetwork(testLookupTable, []int{2, 3, 1})
 	if err != nil {
 		t.Fatalf("Failed to create NewPerceptronNetwork: %s", err.Error())
@@ -23,7 +22,7 @@ func TestPerceptronXOR(t *testing.T) {
 		Iterations:   10000,
 		CostFunction: &automata.MeanSquaredErrorCost{},
 	}
-	err = trainer.Train([]automata.TrainSet{
+	_, err = trainer.Train([]automata.TrainSet{
 		{[]float64{0, 0}, []float64{0}},
 		{[]float64{0, 1}, []float64{1}},
 		{[]float64{1, 0}, []float64{1}},
@@ -41,7 +40,8 @@ func TestPerceptronXOR(t *testing.T) {
 }
 
 func TestPerceptronSine(t *testing.T) {
-	testLookupTable := &automata.LookupTable{}
+	rng := rand.New(rand.NewSource(1))
+	testLookupTable := &automata.LookupTable{Rand: rng}
 	perceptron, err := automata.NewPerceptronNetwork(testLookupTable, []int{1, 12, 1})
 	if err != nil {
 		t.Fatalf("Failed to create NewPerceptronNetwork: %s", err.Error())
@@ -62,13 +62,13 @@ func TestPerceptronSine(t *testing.T) {
 	}
 	var ts []automata.TrainSet
 	for i := 0; i < 500; i++ {
-		rads := rand.Float64() * math.Pi * 2 // random radians
+		rads := rng.Float64() * math.Pi * 2 // random radians
 		ts = append(ts, automata.TrainSet{
 			Input:  []float64{rads},
 			Output: []float64{sinFunc(rads)},
 		})
 	}
-	err = trainer.Train(ts)
+	_, err = trainer.Train(ts)
 	if err != nil {
 		t.Fatalf("trainer.Train threw error: %s", err.Error())
 	}
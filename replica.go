@@ -0,0 +1,119 @@
+package automata
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// replicas are copies of the network being trained, which compute the gradients of shards of each batch
+// concurrently.
+type replicas struct {
+	networks []Networker
+	tables   []*LookupTable
+	ids      []cloneIDs // maps between the IDs of the network and each replica
+}
+
+// newReplicas makes 'count' copies of the network, which accumulate gradients using the given cost function.
+func newReplicas(network Networker, count int, cost Coster) (*replicas, error) {
+	r := &replicas{}
+	for i := 0; i < count; i++ {
+		replica, ids, err := cloneNetworker(network)
+		if err != nil {
+			return nil, err
+		}
+		table := networkTable(replica)
+		table.CostFunction = cost
+		table.AccumulateGradients = true
+		r.networks = append(r.networks, replica)
+		r.tables = append(r.tables, table)
+		r.ids = append(r.ids, ids)
+	}
+	return r, nil
+}
+
+// cloneNetworker returns a clone of the network and the mapping between their IDs.
+func cloneNetworker(network Networker) (Networker, cloneIDs, error) {
+	switch network := network.(type) {
+	case *Network:
+		clone, ids := network.clone()
+		return clone, ids, nil
+	case *Hopfield:
+		clone, ids := network.Network.clone()
+		return &Hopfield{Network: *clone}, ids, nil
+	}
+	return nil, cloneIDs{}, fmt.Errorf("Train: cannot make replicas of %T, only *Network and *Hopfield", network)
+}
+
+// trainBatch splits the batch into a contiguous shard for each replica, which activate and propagate their
+// shards concurrently. The gradients of every replica are added to the table's accumulated gradients in order,
+// so the result does not depend on which replica finishes first. Returns the total cost of the batch.
+func (r *replicas) trainBatch(ctx context.Context, table *LookupTable, batch []TrainSet, rate float64, coster Coster) (float64, error) {
+	costs := make([]float64, len(r.networks))
+	errs := make([]error, len(r.networks))
+	size := (len(batch) + len(r.networks) - 1) / len(r.networks)
+	var wg sync.WaitGroup
+	for i := range r.networks {
+		start, end := i*size, (i+1)*size
+		if start >= len(batch) {
+			break
+		}
+		if end > len(batch) {
+			end = len(batch)
+		}
+		wg.Add(1)
+		go func(i int, shard []TrainSet) {
+			defer wg.Done()
+			for _, s := range shard {
+				if errs[i] = ctx.Err(); errs[i] != nil {
+					return
+				}
+				output, err := r.networks[i].Activate(s.Input)
+				if err != nil {
+					errs[i] = err
+					return
+				}
+				if err = r.networks[i].Propagate(rate, s.Output); err != nil {
+					errs[i] = err
+					return
+				}
+				costs[i] += coster.Cost(s.Output, output)
+			}
+		}(i, batch[start:end])
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			r.reset()
+			return 0, err
+		}
+	}
+	var cost float64
+	for i := range r.networks {
+		table.addGradients(r.tables[i], r.ids[i])
+		cost += costs[i]
+	}
+	return cost, nil
+}
+
+// sync copies the weights and biases of the table to every replica.
+func (r *replicas) sync(table *LookupTable) {
+	for i, replicaTable := range r.tables {
+		for oldID, id := range r.ids[i].conns {
+			if id >= 0 {
+				replicaTable.Connections[id].Weight = table.Connections[oldID].Weight
+			}
+		}
+		for id, oldID := range r.ids[i].neurons {
+			replicaTable.Neurons[id].Bias = table.Neurons[oldID].Bias
+		}
+	}
+}
+
+// reset discards the gradients accumulated by every replica.
+func (r *replicas) reset() {
+	for _, table := range r.tables {
+		table.ResetGradients()
+	}
+}
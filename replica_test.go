@@ -0,0 +1,73 @@
+package automata
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestTrainReplicas(t *testing.T) {
+	sets := []TrainSet{
+		{[]float64{0, 0}, []float64{0}},
+		{[]float64{0, 1}, []float64{1}},
+		{[]float64{1, 0}, []float64{1}},
+		{[]float64{1, 1}, []float64{0}},
+		{[]float64{0.5, 0}, []float64{1}},
+	}
+	train := func(network Networker, sets []TrainSet, replicas int) (*TrainResult, parameters) {
+		trainer := Trainer{
+			Network:      network,
+			LearnRate:    0.5,
+			Iterations:   20,
+			CostFunction: &CrossEntropyCost{},
+			Optimizer:    &AdamOptimizer{},
+			BatchSize:    4,
+			Replicas:     replicas,
+		}
+		result, err := trainer.Train(sets)
+		if err != nil {
+			t.Fatalf("trainer.Train threw error: %s", err.Error())
+		}
+		return result, networkTable(network).saveParameters()
+	}
+	perceptron := func() *Network {
+		network, _ := NewPerceptronNetwork(&LookupTable{Rand: rand.New(rand.NewSource(1))}, []int{2, 4, 1})
+		return network
+	}
+
+	// feed-forward networks get the same gradients however the batches are split, up to rounding
+	want, wantParams := train(perceptron(), sets, 0)
+	got, gotParams := train(perceptron(), sets, 3)
+	for i := range want.ErrorHistory {
+		if math.Abs(got.ErrorHistory[i]-want.ErrorHistory[i]) > 1e-9 {
+			t.Fatalf("want error history %v, got %v", want.ErrorHistory, got.ErrorHistory)
+		}
+	}
+	for i := range wantParams.weights {
+		if math.Abs(gotParams.weights[i]-wantParams.weights[i]) > 1e-9 {
+			t.Fatalf("want weights %v, got %v", wantParams.weights, gotParams.weights)
+		}
+	}
+	// and the order replicas finish in does not matter
+	if _, again := train(perceptron(), sets, 3); !reflect.DeepEqual(again, gotParams) {
+		t.Errorf("want training with replicas to be repeatable, got %v then %v", gotParams, again)
+	}
+
+	hopfield := func() *Hopfield {
+		return NewHopfieldNetwork(&LookupTable{Rand: rand.New(rand.NewSource(1))}, 2)
+	}
+	images := []TrainSet{{[]float64{0, 1}, []float64{0, 1}}, {[]float64{1, 0}, []float64{1, 0}}}
+	_, wantParams = train(hopfield(), images, 0)
+	_, gotParams = train(hopfield(), images, 2)
+	for i := range wantParams.weights {
+		if math.Abs(gotParams.weights[i]-wantParams.weights[i]) > 1e-9 {
+			t.Fatalf("Hopfield: want weights %v, got %v", wantParams.weights, gotParams.weights)
+		}
+	}
+
+	trainer := Trainer{Network: perceptron(), Iterations: 1, CostFunction: &MeanSquaredErrorCost{}, Replicas: 2}
+	if _, err := trainer.Train(sets); err == nil {
+		t.Errorf("Train: expected error using Replicas without batches, got nil")
+	}
+}
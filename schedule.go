@@ -0,0 +1,129 @@
+package automata
+
+import "math"
+
+// LearnRateSchedule decides the learning rate to use for each training iteration.
+type LearnRateSchedule interface {
+	// LearnRate returns the learning rate for the given iteration, starting from 0. 'baseRate' is the
+	// Trainer.LearnRate. 'errRate' is the error rate of the previous iteration, or +Inf on the first iteration.
+	LearnRate(iteration int, baseRate, errRate float64) float64
+}
+
+// ConstantSchedule uses the base learning rate for every iteration. This is the default.
+type ConstantSchedule struct{}
+
+// LearnRate for the iteration.
+func (s *ConstantSchedule) LearnRate(iteration int, baseRate, errRate float64) float64 {
+	return baseRate
+}
+
+// StepDecaySchedule multiplies the learning rate by Drop every Step iterations.
+type StepDecaySchedule struct {
+	Drop float64
+	Step int
+}
+
+// LearnRate for the iteration.
+func (s *StepDecaySchedule) LearnRate(iteration int, baseRate, errRate float64) float64 {
+	if s.Step < 1 {
+		return baseRate
+	}
+	return baseRate * math.Pow(s.Drop, float64(iteration/s.Step))
+}
+
+// ExponentialDecaySchedule decays the learning rate exponentially: baseRate * e^(-Decay * iteration)
+type ExponentialDecaySchedule struct {
+	Decay float64
+}
+
+// LearnRate for the iteration.
+func (s *ExponentialDecaySchedule) LearnRate(iteration int, baseRate, errRate float64) float64 {
+	return baseRate * math.Exp(-s.Decay*float64(iteration))
+}
+
+// InverseTimeDecaySchedule decays the learning rate in proportion to the iteration: baseRate / (1 + Decay * iteration)
+type InverseTimeDecaySchedule struct {
+	Decay float64
+}
+
+// LearnRate for the iteration.
+func (s *InverseTimeDecaySchedule) LearnRate(iteration int, baseRate, errRate float64) float64 {
+	return baseRate / (1 + s.Decay*float64(iteration))
+}
+
+// CosineAnnealingSchedule anneals the learning rate from the base rate down to MinRate following a cosine curve
+// over Period iterations, then restarts from the base rate. Each period is PeriodMult times longer than the last.
+// See: https://arxiv.org/abs/1608.03983
+type CosineAnnealingSchedule struct {
+	MinRate float64
+	Period  int
+	// PeriodMult defaults to 1 if less than 1, which keeps every period the same length.
+	PeriodMult float64
+}
+
+// LearnRate for the iteration.
+func (s *CosineAnnealingSchedule) LearnRate(iteration int, baseRate, errRate float64) float64 {
+	if s.Period < 1 {
+		return baseRate
+	}
+	mult := math.Max(s.PeriodMult, 1)
+	pos, period := float64(iteration), float64(s.Period)
+	for pos >= period {
+		pos -= period
+		period *= mult
+	}
+	return s.MinRate + 0.5*(baseRate-s.MinRate)*(1+math.Cos(math.Pi*pos/period))
+}
+
+// LinearWarmupSchedule increases the learning rate linearly from baseRate/Steps up to the base rate over the
+// first Steps iterations. After that, the Then schedule is used with iterations counted from the end of the
+// warmup. If Then is nil, the base rate is used.
+type LinearWarmupSchedule struct {
+	Steps int
+	Then  LearnRateSchedule
+}
+
+// LearnRate for the iteration.
+func (s *LinearWarmupSchedule) LearnRate(iteration int, baseRate, errRate float64) float64 {
+	if iteration < s.Steps {
+		return baseRate * float64(iteration+1) / float64(s.Steps)
+	}
+	if s.Then == nil {
+		return baseRate
+	}
+	return s.Then.LearnRate(iteration-s.Steps, baseRate, errRate)
+}
+
+// ReduceOnPlateauSchedule multiplies the learning rate by Factor when the error rate has not improved by more
+// than MinDelta for Patience iterations. The learning rate is never reduced below MinRate. This schedule keeps
+// state between iterations, so a new one should be used for each training run.
+type ReduceOnPlateauSchedule struct {
+	Factor   float64
+	Patience int
+	MinDelta float64
+	MinRate  float64
+
+	started bool
+	best    float64
+	wait    int
+	scale   float64
+}
+
+// LearnRate for the iteration.
+func (s *ReduceOnPlateauSchedule) LearnRate(iteration int, baseRate, errRate float64) float64 {
+	if !s.started {
+		s.started = true
+		s.best = math.Inf(1)
+		s.scale = 1
+	}
+	if !math.IsInf(errRate, 1) {
+		if errRate < s.best-s.MinDelta {
+			s.best = errRate
+			s.wait = 0
+		} else if s.wait++; s.wait >= s.Patience {
+			s.scale *= s.Factor
+			s.wait = 0
+		}
+	}
+	return math.Max(baseRate*s.scale, s.MinRate)
+}
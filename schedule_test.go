@@ -0,0 +1,51 @@
+package automata
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLearnRateSchedules(t *testing.T) {
+	inf := math.Inf(1)
+	testCases := []struct {
+		name     string
+		schedule LearnRateSchedule
+		want     []float64 // learning rates for iterations 0-5 with a base rate of 1
+	}{
+		{"constant", &ConstantSchedule{}, []float64{1, 1, 1, 1, 1, 1}},
+		{"step", &StepDecaySchedule{Drop: 0.5, Step: 2}, []float64{1, 1, 0.5, 0.5, 0.25, 0.25}},
+		{"exponential", &ExponentialDecaySchedule{Decay: 1}, []float64{1, math.Exp(-1), math.Exp(-2), math.Exp(-3), math.Exp(-4), math.Exp(-5)}},
+		{"inverse time", &InverseTimeDecaySchedule{Decay: 1}, []float64{1, 1.0 / 2, 1.0 / 3, 1.0 / 4, 1.0 / 5, 1.0 / 6}},
+		{"cosine", &CosineAnnealingSchedule{Period: 2}, []float64{1, 0.5, 1, 0.5, 1, 0.5}},
+		{"cosine restarts", &CosineAnnealingSchedule{Period: 2, PeriodMult: 2}, []float64{1, 0.5, 1, 0.5 + 0.5*math.Cos(math.Pi/4), 0.5, 0.5 - 0.5*math.Cos(math.Pi/4)}},
+		{"warmup", &LinearWarmupSchedule{Steps: 4, Then: &StepDecaySchedule{Drop: 0.1, Step: 1}}, []float64{0.25, 0.5, 0.75, 1, 1, 0.1}},
+	}
+	for _, tc := range testCases {
+		for i, want := range tc.want {
+			if got := tc.schedule.LearnRate(i, 1, inf); math.Abs(got-want) > 1e-9 {
+				t.Errorf("%s: iteration %d: want %v, got %v", tc.name, i, want, got)
+			}
+		}
+	}
+}
+
+func TestReduceOnPlateauSchedule(t *testing.T) {
+	s := &ReduceOnPlateauSchedule{Factor: 0.5, Patience: 1, MinRate: 0.3}
+	errRates := []float64{math.Inf(1), 1, 0.5, 0.5, 0.5, 0.4, 0.4, 0.4, 0.4, 0.4}
+	want := []float64{1, 1, 1, 0.5, 0.3, 0.3, 0.3, 0.3, 0.3, 0.3}
+	for i := range errRates {
+		if got := s.LearnRate(i, 1, errRates[i]); got != want[i] {
+			t.Errorf("iteration %d: want %v, got %v", i, want[i], got)
+		}
+	}
+
+	// the rate is reduced on exactly the Patience'th iteration without improvement
+	s = &ReduceOnPlateauSchedule{Factor: 0.5, Patience: 3}
+	errRates = []float64{math.Inf(1), 1, 1, 1, 1, 1, 1, 1}
+	want = []float64{1, 1, 1, 1, 0.5, 0.5, 0.5, 0.25}
+	for i := range errRates {
+		if got := s.LearnRate(i, 1, errRates[i]); got != want[i] {
+			t.Errorf("patience 3: iteration %d: want %v, got %v", i, want[i], got)
+		}
+	}
+}
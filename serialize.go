@@ -0,0 +1,336 @@
+package automata
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// networkSnapshot is a pointer-free representation of a Network and the LookupTable it uses. Neurons and
+// connections refer to each other by ID, which allows the snapshot to be encoded and later rebuilt into a
+// fresh LookupTable with all the pointers rewired.
+type networkSnapshot struct {
+	Neurons     []neuronSnapshot     `json:"neurons"`
+	Connections []connectionSnapshot `json:"connections"`
+	Input       layerSnapshot        `json:"input"`
+	Hidden      []layerSnapshot      `json:"hidden"`
+	Output      layerSnapshot        `json:"output"`
+}
+
+type neuronSnapshot struct {
+	ID         NeuronID   `json:"id"`
+	Old        float64    `json:"old"`
+	State      float64    `json:"state"`
+	Derivative float64    `json:"derivative"`
+	Activation float64    `json:"activation"`
+	Self       ConnID     `json:"self"`
+	Squash     string     `json:"squash"`
+	Bias       float64    `json:"bias"`
+	Neighbours []NeuronID `json:"neighbours,omitempty"`
+
+	Inputs    []ConnID `json:"inputs,omitempty"`
+	Projected []ConnID `json:"projected,omitempty"`
+	Gated     []ConnID `json:"gated,omitempty"`
+
+	ErrorResponsibility float64 `json:"error_responsibility"`
+	ErrorProjected      float64 `json:"error_projected"`
+	ErrorGated          float64 `json:"error_gated"`
+
+	TraceEligibility []float64                       `json:"trace_eligibility,omitempty"`
+	TraceExtended    map[NeuronID]map[ConnID]float64 `json:"trace_extended,omitempty"`
+	TraceInfluences  map[NeuronID][]ConnID           `json:"trace_influences,omitempty"`
+}
+
+type connectionSnapshot struct {
+	ID     ConnID    `json:"id"`
+	From   NeuronID  `json:"from"`
+	To     NeuronID  `json:"to"`
+	Gater  *NeuronID `json:"gater,omitempty"`
+	Weight float64   `json:"weight"`
+	Gain   float64   `json:"gain"`
+}
+
+type layerSnapshot struct {
+	Neurons     []NeuronID                `json:"neurons"`
+	ConnectedTo []layerConnectionSnapshot `json:"connected_to,omitempty"`
+	Activation  LayerActivation           `json:"activation,omitempty"`
+}
+
+// layerConnectionSnapshot refers to layers by their position in the network: 0 is the input layer, followed by
+// each hidden layer in order, with the output layer last.
+type layerConnectionSnapshot struct {
+	From        int       `json:"from"`
+	To          int       `json:"to"`
+	Type        LayerType `json:"type"`
+	Connections []ConnID  `json:"connections"`
+}
+
+// MarshalJSON encodes the network along with every neuron and connection in its LookupTable.
+func (n *Network) MarshalJSON() ([]byte, error) {
+	snap, err := snapshotNetwork(n)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(snap)
+}
+
+// UnmarshalJSON decodes a network previously encoded with MarshalJSON. A new LookupTable is created to hold
+// the decoded neurons and connections, which can be accessed via the LookupTable field of any layer.
+func (n *Network) UnmarshalJSON(data []byte) error {
+	var snap networkSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return err
+	}
+	network, err := snap.restore(&LookupTable{})
+	if err != nil {
+		return err
+	}
+	*n = *network
+	return nil
+}
+
+// layers returns all the layers in this network in activation order.
+func (n *Network) layers() []*Layer {
+	layers := []*Layer{n.Input}
+	for i := range n.Hidden {
+		layers = append(layers, &n.Hidden[i])
+	}
+	return append(layers, n.Output)
+}
+
+// snapshotNetwork creates a snapshot of the network and its LookupTable.
+func snapshotNetwork(n *Network) (*networkSnapshot, error) {
+	table := n.LookupTable()
+	var snap networkSnapshot
+	for _, neuron := range table.Neurons {
+		squash, err := SquasherName(neuron.Squash)
+		if err != nil {
+			return nil, fmt.Errorf("neuron %d: %s", neuron.ID, err)
+		}
+		snap.Neurons = append(snap.Neurons, neuronSnapshot{
+			ID:                  neuron.ID,
+			Old:                 neuron.Old,
+			State:               neuron.State,
+			Derivative:          neuron.Derivative,
+			Activation:          neuron.Activation,
+			Self:                neuron.Self.ID,
+			Squash:              squash,
+			Bias:                neuron.Bias,
+			Neighbours:          neuron.Neighbours,
+			Inputs:              neuron.Inputs,
+			Projected:           neuron.Projected,
+			Gated:               neuron.Gated,
+			ErrorResponsibility: neuron.ErrorResponsibility,
+			ErrorProjected:      neuron.ErrorProjected,
+			ErrorGated:          neuron.ErrorGated,
+			TraceEligibility:    neuron.TraceEligibility,
+			TraceExtended:       neuron.TraceExtended,
+			TraceInfluences:     neuron.TraceInfluences,
+		})
+	}
+	for _, conn := range table.Connections {
+		if conn == nil {
+			continue
+		}
+		cs := connectionSnapshot{
+			ID:     conn.ID,
+			From:   conn.From.ID,
+			To:     conn.To.ID,
+			Weight: conn.Weight,
+			Gain:   conn.Gain,
+		}
+		if conn.Gater != nil {
+			gater := conn.Gater.ID
+			cs.Gater = &gater
+		}
+		snap.Connections = append(snap.Connections, cs)
+	}
+
+	layers := n.layers()
+	layerIndex := func(l *Layer) int {
+		for i, candidate := range layers {
+			if candidate == l || sameNeurons(candidate, l) {
+				return i
+			}
+		}
+		return -1
+	}
+	snapLayers := make([]layerSnapshot, len(layers))
+	for i, l := range layers {
+		snapLayers[i].Activation = l.Activation
+		for _, neuron := range l.List {
+			snapLayers[i].Neurons = append(snapLayers[i].Neurons, neuron.ID)
+		}
+		for _, lc := range l.ConnectedTo {
+			from, to := layerIndex(lc.From), layerIndex(lc.To)
+			if from == -1 || to == -1 {
+				continue // connects to a layer outside of this network
+			}
+			lcs := layerConnectionSnapshot{
+				From: from,
+				To:   to,
+				Type: lc.Type,
+			}
+			for _, conn := range lc.List {
+				lcs.Connections = append(lcs.Connections, conn.ID)
+			}
+			snapLayers[i].ConnectedTo = append(snapLayers[i].ConnectedTo, lcs)
+		}
+	}
+	snap.Input = snapLayers[0]
+	snap.Hidden = snapLayers[1 : len(snapLayers)-1]
+	snap.Output = snapLayers[len(snapLayers)-1]
+	return &snap, nil
+}
+
+// restore the snapshot into the given LookupTable, which should be empty.
+func (s *networkSnapshot) restore(table *LookupTable) (*Network, error) {
+	for i, ns := range s.Neurons {
+		if int(ns.ID) != i {
+			return nil, fmt.Errorf("neuron at position %d has ID %d", i, ns.ID)
+		}
+		squash, err := NewSquasher(ns.Squash)
+		if err != nil {
+			return nil, fmt.Errorf("neuron %d: %s", ns.ID, err)
+		}
+		neuron := &Neuron{
+			ID:                  ns.ID,
+			Old:                 ns.Old,
+			State:               ns.State,
+			Derivative:          ns.Derivative,
+			Activation:          ns.Activation,
+			Squash:              squash,
+			Bias:                ns.Bias,
+			Neighbours:          ns.Neighbours,
+			Inputs:              ns.Inputs,
+			Projected:           ns.Projected,
+			Gated:               ns.Gated,
+			ErrorResponsibility: ns.ErrorResponsibility,
+			ErrorProjected:      ns.ErrorProjected,
+			ErrorGated:          ns.ErrorGated,
+			TraceEligibility:    ns.TraceEligibility,
+			TraceExtended:       ns.TraceExtended,
+			TraceInfluences:     ns.TraceInfluences,
+			LookupTable:         table,
+		}
+		if neuron.TraceExtended == nil {
+			neuron.TraceExtended = make(map[NeuronID]map[ConnID]float64)
+		}
+		if neuron.TraceInfluences == nil {
+			neuron.TraceInfluences = make(map[NeuronID][]ConnID)
+		}
+		table.SetNeuron(neuron)
+	}
+
+	for _, cs := range s.Connections {
+		if cs.ID < 0 {
+			return nil, fmt.Errorf("connection has negative ID %d", cs.ID)
+		}
+		from, to := table.GetNeuron(cs.From), table.GetNeuron(cs.To)
+		if from == nil || to == nil {
+			return nil, fmt.Errorf("connection %d references unknown neuron", cs.ID)
+		}
+		conn := &Connection{
+			ID:     cs.ID,
+			From:   from,
+			To:     to,
+			Weight: cs.Weight,
+			Gain:   cs.Gain,
+		}
+		if cs.Gater != nil {
+			if conn.Gater = table.GetNeuron(*cs.Gater); conn.Gater == nil {
+				return nil, fmt.Errorf("connection %d is gated by unknown neuron %d", cs.ID, *cs.Gater)
+			}
+		}
+		table.SetConnectionWithID(cs.ID, conn)
+	}
+
+	// check every referenced ID resolves before handing the network out
+	checkConns := func(nid NeuronID, ids []ConnID) error {
+		for _, id := range ids {
+			if table.GetConnection(id) == nil {
+				return fmt.Errorf("neuron %d references unknown connection %d", nid, id)
+			}
+		}
+		return nil
+	}
+	for i, ns := range s.Neurons {
+		neuron := table.Neurons[i]
+		if neuron.Self = table.GetConnection(ns.Self); neuron.Self == nil {
+			return nil, fmt.Errorf("neuron %d references unknown self connection %d", ns.ID, ns.Self)
+		}
+		for _, ids := range [][]ConnID{ns.Inputs, ns.Projected, ns.Gated} {
+			if err := checkConns(ns.ID, ids); err != nil {
+				return nil, err
+			}
+		}
+		for nid, ids := range ns.TraceInfluences {
+			if err := checkConns(ns.ID, ids); err != nil {
+				return nil, err
+			}
+			if table.GetNeuron(nid) == nil {
+				return nil, fmt.Errorf("neuron %d references unknown neuron %d", ns.ID, nid)
+			}
+		}
+		for nid := range ns.TraceExtended {
+			if table.GetNeuron(nid) == nil {
+				return nil, fmt.Errorf("neuron %d references unknown neuron %d", ns.ID, nid)
+			}
+		}
+	}
+
+	snapLayers := append(append([]layerSnapshot{s.Input}, s.Hidden...), s.Output)
+	layers := make([]Layer, len(snapLayers))
+	for i, ls := range snapLayers {
+		layers[i].LookupTable = table
+		layers[i].Activation = ls.Activation
+		for _, nid := range ls.Neurons {
+			neuron := table.GetNeuron(nid)
+			if neuron == nil {
+				return nil, fmt.Errorf("layer %d references unknown neuron %d", i, nid)
+			}
+			layers[i].List = append(layers[i].List, neuron)
+		}
+	}
+	for i, ls := range snapLayers {
+		for _, lcs := range ls.ConnectedTo {
+			if lcs.From < 0 || lcs.From >= len(layers) || lcs.To < 0 || lcs.To >= len(layers) {
+				return nil, fmt.Errorf("layer %d has a connection to unknown layer", i)
+			}
+			lc := LayerConnection{
+				From:        &layers[lcs.From],
+				To:          &layers[lcs.To],
+				Type:        lcs.Type,
+				Connections: make(map[ConnID]*Connection),
+			}
+			for _, id := range lcs.Connections {
+				conn := table.GetConnection(id)
+				if conn == nil {
+					return nil, fmt.Errorf("layer %d references unknown connection %d", i, id)
+				}
+				lc.Connections[id] = conn
+				lc.List = append(lc.List, conn)
+			}
+			layers[i].ConnectedTo = append(layers[i].ConnectedTo, lc)
+		}
+	}
+
+	return &Network{
+		Input:  &layers[0],
+		Hidden: layers[1 : len(layers)-1],
+		Output: &layers[len(layers)-1],
+	}, nil
+}
+
+// sameNeurons returns true if both layers contain exactly the same neurons. Layers are often copied by value
+// when building networks, so this is used to identify a layer regardless of which copy is referenced.
+func sameNeurons(a, b *Layer) bool {
+	if len(a.List) != len(b.List) || len(a.List) == 0 {
+		return false
+	}
+	for i := range a.List {
+		if a.List[i] != b.List[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,82 @@
+package automata_test
+
+import (
+	"encoding/json"
+	"github.com/Kegsay/automata"
+	"testing"
+)
+
+func TestNetworkJSONRoundTrip(t *testing.T) {
+	testLookupTable := &automata.LookupTable{}
+	lstm := automata.NewLSTM(testLookupTable, 2, []int{3, 2}, 1)
+	lstm.Hidden[0].Activation = automata.LayerActivationSoftmax
+	trainer := automata.Trainer{
+		Network:      lstm,
+		MaxErrorRate: 0.001,
+		LearnRate:    0.1,
+		Iterations:   5,
+		CostFunction: &automata.MeanSquaredErrorCost{},
+	}
+	if _, err := trainer.Train([]automata.TrainSet{
+		{[]float64{0, 1}, []float64{1}},
+		{[]float64{1, 0}, []float64{0}},
+	}); err != nil {
+		t.Fatalf("trainer.Train threw error: %s", err.Error())
+	}
+
+	data, err := json.Marshal(lstm)
+	if err != nil {
+		t.Fatalf("json.Marshal threw error: %s", err.Error())
+	}
+	var loaded automata.Network
+	if err = json.Unmarshal(data, &loaded); err != nil {
+		t.Fatalf("json.Unmarshal threw error: %s", err.Error())
+	}
+	if loaded.Input.LookupTable == testLookupTable {
+		t.Fatalf("json.Unmarshal reused the original LookupTable")
+	}
+	if got, want := len(loaded.Input.LookupTable.Connections), len(testLookupTable.Connections); got != want {
+		t.Errorf("connection count: want %d, got %d", want, got)
+	}
+	if loaded.Hidden[0].Activation != automata.LayerActivationSoftmax {
+		t.Errorf("layer activation: want softmax, got %v", loaded.Hidden[0].Activation)
+	}
+
+	// both networks must behave identically, including their recurrent state
+	for _, input := range [][]float64{{0, 0}, {0, 1}, {1, 1}, {1, 0}} {
+		want, _ := lstm.Activate(input)
+		got, _ := loaded.Activate(input)
+		if want[0] != got[0] {
+			t.Errorf("Activate(%v): want %v, got %v", input, want[0], got[0])
+		}
+	}
+}
+
+func TestHopfieldJSONRoundTrip(t *testing.T) {
+	testLookupTable := &automata.LookupTable{}
+	hopfield := automata.NewHopfieldNetwork(testLookupTable, 4)
+	data, err := json.Marshal(hopfield)
+	if err != nil {
+		t.Fatalf("json.Marshal threw error: %s", err.Error())
+	}
+	var loaded automata.Hopfield
+	if err = json.Unmarshal(data, &loaded); err != nil {
+		t.Fatalf("json.Unmarshal threw error: %s", err.Error())
+	}
+	input := []float64{1, 0, 1, 0}
+	want, _ := hopfield.Activate(input)
+	got, _ := loaded.Activate(input)
+	for i := range want {
+		if want[i] != got[i] {
+			t.Errorf("Activate(%v): want %v, got %v", input, want, got)
+		}
+	}
+}
+
+func TestNetworkJSONBadReference(t *testing.T) {
+	var network automata.Network
+	err := json.Unmarshal([]byte(`{"neurons":[],"connections":[{"id":0,"from":3,"to":4}]}`), &network)
+	if err == nil {
+		t.Errorf("json.Unmarshal: expected error for unknown neuron, got nil")
+	}
+}
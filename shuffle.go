@@ -0,0 +1,94 @@
+package automata
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// ShuffleMode controls the order in which the Trainer uses the training set.
+type ShuffleMode int
+
+const (
+	// ShuffleOff trains on the training set in order. This is the default.
+	ShuffleOff ShuffleMode = iota
+	// ShuffleEpoch shuffles the training set before every iteration.
+	ShuffleEpoch
+)
+
+// defaultShuffleSeed seeds the source used for shuffling if Trainer.ShuffleSource is nil.
+const defaultShuffleSeed = 1
+
+// shuffler shuffles the training set for a single training run.
+type shuffler struct {
+	source   *countingSource
+	rand     *rand.Rand
+	initial  [][]TrainSet // each sequence, or each sample if not sequential
+	units    [][]TrainSet
+	shuffled []TrainSet
+}
+
+// newShuffler returns a shuffler for the training set. 'sequential' is the length of each sequence in the training
+// set, or nil if every sample can be shuffled. 'draws' is the number of values to skip in the source, which is used
+// to pick up where a checkpoint left off.
+func newShuffler(set []TrainSet, sequential []int, src rand.Source, draws int64) (*shuffler, error) {
+	var initial [][]TrainSet
+	if sequential == nil {
+		for i := range set {
+			initial = append(initial, set[i:i+1])
+		}
+	} else {
+		total := 0
+		for _, length := range sequential {
+			if length < 1 {
+				return nil, fmt.Errorf("Train: Sequential lengths must be at least 1, got %d", length)
+			}
+			if total+length <= len(set) {
+				initial = append(initial, set[total:total+length])
+			}
+			total += length
+		}
+		if total != len(set) {
+			return nil, fmt.Errorf("Train: Sequential lengths add up to %d, but there are %d training sets", total, len(set))
+		}
+	}
+	if src == nil {
+		src = rand.NewSource(defaultShuffleSeed)
+	}
+	source := &countingSource{Source: src}
+	for source.draws < draws {
+		source.Int63()
+	}
+	return &shuffler{
+		source:   source,
+		rand:     rand.New(source),
+		initial:  initial,
+		units:    make([][]TrainSet, len(initial)),
+		shuffled: make([]TrainSet, 0, len(set)),
+	}, nil
+}
+
+// shuffle returns a new order for the training set. Every call starts from the original order, so the result
+// only depends on the values drawn from the source.
+func (s *shuffler) shuffle() []TrainSet {
+	copy(s.units, s.initial)
+	s.rand.Shuffle(len(s.units), func(i, j int) {
+		s.units[i], s.units[j] = s.units[j], s.units[i]
+	})
+	s.shuffled = s.shuffled[:0]
+	for _, unit := range s.units {
+		s.shuffled = append(s.shuffled, unit...)
+	}
+	return s.shuffled
+}
+
+// countingSource counts the values drawn from a source so a checkpoint can record how far through it training got.
+// It deliberately does not implement rand.Source64, so every value is drawn with Int63.
+type countingSource struct {
+	rand.Source
+	draws int64
+}
+
+func (s *countingSource) Int63() int64 {
+	s.draws++
+	return s.Source.Int63()
+}
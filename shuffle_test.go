@@ -0,0 +1,70 @@
+package automata
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestShuffleSequential(t *testing.T) {
+	sets := make([]TrainSet, 6)
+	for i := range sets {
+		sets[i].Input = []float64{float64(i)}
+	}
+	sequences := [][]float64{{0, 1}, {2, 3, 4}, {5}}
+	s, err := newShuffler(sets, []int{2, 3, 1}, rand.NewSource(42), 0)
+	if err != nil {
+		t.Fatalf("newShuffler threw error: %s", err.Error())
+	}
+	orders := make(map[string]bool)
+	for epoch := 0; epoch < 20; epoch++ {
+		shuffled := s.shuffle()
+		if len(shuffled) != len(sets) {
+			t.Fatalf("want %d sets, got %d", len(sets), len(shuffled))
+		}
+		var order []float64
+		for _, set := range shuffled {
+			order = append(order, set.Input[0])
+		}
+		orders[fmt.Sprint(order)] = true
+		// every sequence must appear in full and in order
+		for i := 0; i < len(order); {
+			found := false
+			for _, seq := range sequences {
+				if order[i] == seq[0] && i+len(seq) <= len(order) && reflect.DeepEqual(order[i:i+len(seq)], seq) {
+					i += len(seq)
+					found = true
+					break
+				}
+			}
+			if !found {
+				t.Fatalf("epoch %d: sequences were split up: %v", epoch, order)
+			}
+		}
+	}
+	if len(orders) < 2 {
+		t.Errorf("want the order of sequences to change between epochs, got %v", orders)
+	}
+
+	if _, err = newShuffler(sets, []int{2, 3}, nil, 0); err == nil {
+		t.Errorf("newShuffler: expected error when Sequential does not cover the training set, got nil")
+	}
+}
+
+func TestShuffleDraws(t *testing.T) {
+	sets := make([]TrainSet, 10)
+	for i := range sets {
+		sets[i].Input = []float64{float64(i)}
+	}
+	s, _ := newShuffler(sets, nil, rand.NewSource(7), 0)
+	s.shuffle()
+	draws := s.source.draws
+	want := append([]TrainSet(nil), s.shuffle()...)
+
+	// skipping the values used by the first shuffle should give the same second shuffle
+	resumed, _ := newShuffler(sets, nil, rand.NewSource(7), draws)
+	if got := resumed.shuffle(); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
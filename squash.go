@@ -1,6 +1,13 @@
 package automata
 
-import "math"
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+)
 
 // Squasher implements a squashing function which can be used as an activation function.
 // Squashing functions modify inputs allowing neurons to model non-linear relationships.
@@ -71,3 +78,253 @@ func (s *SquashRelu) Squash(x float64, derivate bool) float64 {
 	}
 	return 0
 }
+
+// SquashLeakyRelu implements the leaky ReLU function, which lets a small gradient through for negative inputs so
+// that neurons cannot get stuck outputting 0.
+type SquashLeakyRelu struct {
+	// Slope for negative inputs. Defaults to 0.01 if 0.
+	Slope float64
+}
+
+// Squash x.
+func (s *SquashLeakyRelu) Squash(x float64, derivate bool) float64 {
+	slope := defaultFloat(s.Slope, 0.01)
+	if derivate {
+		if x > 0 {
+			return 1
+		}
+		return slope
+	}
+	if x > 0 {
+		return x
+	}
+	return slope * x
+}
+
+// SquashElu implements the exponential linear unit function.
+// See: https://arxiv.org/abs/1511.07289
+type SquashElu struct {
+	// Alpha is the value negative inputs saturate to. Defaults to 1 if 0.
+	Alpha float64
+}
+
+// Squash x.
+func (s *SquashElu) Squash(x float64, derivate bool) float64 {
+	alpha := defaultFloat(s.Alpha, 1)
+	if x > 0 {
+		if derivate {
+			return 1
+		}
+		return x
+	}
+	if derivate {
+		return alpha * math.Exp(x)
+	}
+	return alpha * (math.Exp(x) - 1)
+}
+
+// SquashSelu implements the scaled exponential linear unit function, which keeps activations normalised in deep
+// perceptrons. See: https://arxiv.org/abs/1706.02515
+type SquashSelu struct{}
+
+const (
+	seluAlpha = 1.6732632423543772848170429916717
+	seluScale = 1.0507009873554804934193349852946
+)
+
+// Squash x.
+func (s *SquashSelu) Squash(x float64, derivate bool) float64 {
+	if x > 0 {
+		if derivate {
+			return seluScale
+		}
+		return seluScale * x
+	}
+	if derivate {
+		return seluScale * seluAlpha * math.Exp(x)
+	}
+	return seluScale * (seluAlpha * (math.Exp(x) - 1))
+}
+
+// SquashSoftplus implements the softplus function ln(1 + e^x), a smooth approximation of ReLU.
+type SquashSoftplus struct{}
+
+// Squash x.
+func (s *SquashSoftplus) Squash(x float64, derivate bool) float64 {
+	if derivate {
+		return 1.0 / (1.0 + math.Exp(-x))
+	}
+	// rearranged so large inputs do not overflow
+	return math.Max(x, 0) + math.Log1p(math.Exp(-math.Abs(x)))
+}
+
+// SquashSwish implements the swish function x * logistic(x). See: https://arxiv.org/abs/1710.05941
+type SquashSwish struct{}
+
+// Squash x.
+func (s *SquashSwish) Squash(x float64, derivate bool) float64 {
+	sig := 1.0 / (1.0 + math.Exp(-x))
+	if derivate {
+		return sig + x*sig*(1-sig)
+	}
+	return x * sig
+}
+
+// SquashGelu implements the Gaussian error linear unit function x * Φ(x), where Φ is the cumulative distribution
+// function of the standard normal distribution. See: https://arxiv.org/abs/1606.08415
+type SquashGelu struct{}
+
+// Squash x.
+func (s *SquashGelu) Squash(x float64, derivate bool) float64 {
+	cdf := 0.5 * (1 + math.Erf(x/math.Sqrt2))
+	if derivate {
+		return cdf + x*math.Exp(-x*x/2)/math.Sqrt(2*math.Pi)
+	}
+	return x * cdf
+}
+
+// SquashGaussian implements the Gaussian function e^(-x^2).
+type SquashGaussian struct{}
+
+// Squash x.
+func (s *SquashGaussian) Squash(x float64, derivate bool) float64 {
+	fx := math.Exp(-x * x)
+	if derivate {
+		return -2 * x * fx
+	}
+	return fx
+}
+
+// SquashSinusoid implements the sine function.
+type SquashSinusoid struct{}
+
+// Squash x.
+func (s *SquashSinusoid) Squash(x float64, derivate bool) float64 {
+	if derivate {
+		return math.Cos(x)
+	}
+	return math.Sin(x)
+}
+
+// SquashHardTanh implements the hard tanh function, which clamps x between -1 and 1.
+type SquashHardTanh struct{}
+
+// Squash x.
+func (s *SquashHardTanh) Squash(x float64, derivate bool) float64 {
+	if derivate {
+		if x > -1 && x < 1 {
+			return 1
+		}
+		return 0
+	}
+	return math.Max(-1, math.Min(1, x))
+}
+
+// SquashBentIdentity implements the bent identity function (sqrt(x^2 + 1) - 1) / 2 + x.
+type SquashBentIdentity struct{}
+
+// Squash x.
+func (s *SquashBentIdentity) Squash(x float64, derivate bool) float64 {
+	root := math.Sqrt(x*x + 1)
+	if derivate {
+		return x/(2*root) + 1
+	}
+	return (root-1)/2 + x
+}
+
+// squasherRegistry maps names to squashers, and squasher types back to names.
+var squasherRegistry = struct {
+	sync.RWMutex
+	byName map[string]func() Squasher
+	byType map[reflect.Type]string
+}{
+	byName: make(map[string]func() Squasher),
+	byType: make(map[reflect.Type]string),
+}
+
+func init() {
+	RegisterSquasher("logistic", func() Squasher { return &SquashLogistic{} })
+	RegisterSquasher("tanh", func() Squasher { return &SquashTanh{} })
+	RegisterSquasher("identity", func() Squasher { return &SquashIdentity{} })
+	RegisterSquasher("relu", func() Squasher { return &SquashRelu{} })
+	RegisterSquasher("leaky_relu", func() Squasher { return &SquashLeakyRelu{} })
+	RegisterSquasher("elu", func() Squasher { return &SquashElu{} })
+	RegisterSquasher("selu", func() Squasher { return &SquashSelu{} })
+	RegisterSquasher("softplus", func() Squasher { return &SquashSoftplus{} })
+	RegisterSquasher("swish", func() Squasher { return &SquashSwish{} })
+	RegisterSquasher("gelu", func() Squasher { return &SquashGelu{} })
+	RegisterSquasher("gaussian", func() Squasher { return &SquashGaussian{} })
+	RegisterSquasher("sinusoid", func() Squasher { return &SquashSinusoid{} })
+	RegisterSquasher("hard_tanh", func() Squasher { return &SquashHardTanh{} })
+	RegisterSquasher("bent_identity", func() Squasher { return &SquashBentIdentity{} })
+}
+
+// RegisterSquasher makes a squasher available by name to NewSquasher and SquasherName, which are used when saving
+// and loading networks. 'newSquasher' must return a new squasher every time it is called, and every squasher it
+// returns must have the same type. Any exported fields of the squasher are saved as its parameters, so it should
+// be a pointer to a struct. RegisterSquasher panics if the name or type is already registered, or if the name
+// contains "{".
+func RegisterSquasher(name string, newSquasher func() Squasher) {
+	if name == "" || strings.Contains(name, "{") {
+		panic(fmt.Sprintf("RegisterSquasher: invalid name %q", name))
+	}
+	squasherType := reflect.TypeOf(newSquasher())
+	squasherRegistry.Lock()
+	defer squasherRegistry.Unlock()
+	if _, ok := squasherRegistry.byName[name]; ok {
+		panic(fmt.Sprintf("RegisterSquasher: name %q is already registered", name))
+	}
+	if existing, ok := squasherRegistry.byType[squasherType]; ok {
+		panic(fmt.Sprintf("RegisterSquasher: type %v is already registered as %q", squasherType, existing))
+	}
+	squasherRegistry.byName[name] = newSquasher
+	squasherRegistry.byType[squasherType] = name
+}
+
+// SquasherName returns the name of a registered squasher. If any of the squasher's parameters are set, they are
+// appended to the name as a JSON object, e.g. `leaky_relu{"Slope":0.2}`.
+func SquasherName(s Squasher) (string, error) {
+	squasherRegistry.RLock()
+	name, ok := squasherRegistry.byType[reflect.TypeOf(s)]
+	squasherRegistry.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("unknown squasher type %T", s)
+	}
+	v := reflect.ValueOf(s)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.IsZero() {
+		return name, nil
+	}
+	params, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+	if string(params) == "{}" {
+		return name, nil
+	}
+	return name + string(params), nil
+}
+
+// NewSquasher returns a new squasher for a name returned by SquasherName. Parameters are optional: "leaky_relu"
+// and `leaky_relu{"Slope":0.2}` are both valid names.
+func NewSquasher(name string) (Squasher, error) {
+	var params string
+	if i := strings.Index(name, "{"); i != -1 {
+		name, params = name[:i], name[i:]
+	}
+	squasherRegistry.RLock()
+	newSquasher, ok := squasherRegistry.byName[name]
+	squasherRegistry.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown squasher name %q", name)
+	}
+	s := newSquasher()
+	if params != "" {
+		if err := json.Unmarshal([]byte(params), s); err != nil {
+			return nil, fmt.Errorf("squasher %q: invalid parameters: %s", name, err)
+		}
+	}
+	return s, nil
+}
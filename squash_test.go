@@ -2,6 +2,7 @@ package automata
 
 import (
 	"math"
+	"reflect"
 	"testing"
 )
 
@@ -51,3 +52,95 @@ func TestSquashRelu(t *testing.T) {
 		t.Errorf("want 0, got %f", out)
 	}
 }
+
+func TestSquashDerivatives(t *testing.T) {
+	squashers := map[string]Squasher{
+		"leaky relu":    &SquashLeakyRelu{Slope: 0.2},
+		"elu":           &SquashElu{Alpha: 0.5},
+		"selu":          &SquashSelu{},
+		"softplus":      &SquashSoftplus{},
+		"swish":         &SquashSwish{},
+		"gelu":          &SquashGelu{},
+		"gaussian":      &SquashGaussian{},
+		"sinusoid":      &SquashSinusoid{},
+		"hard tanh":     &SquashHardTanh{},
+		"bent identity": &SquashBentIdentity{},
+	}
+	for name, s := range squashers {
+		for _, x := range []float64{-2.5, -0.7, -0.1, 0.3, 0.9, 3} {
+			numerical := (s.Squash(x+1e-6, false) - s.Squash(x-1e-6, false)) / 2e-6
+			if got := s.Squash(x, true); math.Abs(got-numerical) > 1e-6 {
+				t.Errorf("%s: derivative at %v: want %v, got %v", name, x, numerical, got)
+			}
+		}
+	}
+}
+
+func TestSquashValues(t *testing.T) {
+	testCases := []struct {
+		name string
+		s    Squasher
+		x    float64
+		want float64
+	}{
+		{"leaky relu default slope", &SquashLeakyRelu{}, -2, -0.02},
+		{"leaky relu", &SquashLeakyRelu{Slope: 0.3}, -2, -0.6},
+		{"elu default alpha", &SquashElu{}, -1, math.Exp(-1) - 1},
+		{"selu", &SquashSelu{}, 1, 1.0507009873554805},
+		{"softplus", &SquashSoftplus{}, 0, math.Ln2},
+		{"softplus large", &SquashSoftplus{}, 1000, 1000},
+		{"swish", &SquashSwish{}, 0, 0},
+		{"gelu", &SquashGelu{}, 1, 0.8413447460685429},
+		{"gaussian", &SquashGaussian{}, 0, 1},
+		{"sinusoid", &SquashSinusoid{}, math.Pi / 2, 1},
+		{"hard tanh", &SquashHardTanh{}, 5, 1},
+		{"bent identity", &SquashBentIdentity{}, 0, 0},
+	}
+	for _, tc := range testCases {
+		if got := tc.s.Squash(tc.x, false); math.Abs(got-tc.want) > 1e-12 {
+			t.Errorf("%s: Squash(%v): want %v, got %v", tc.name, tc.x, tc.want, got)
+		}
+	}
+}
+
+func TestSquasherRegistry(t *testing.T) {
+	testCases := []struct {
+		s    Squasher
+		name string
+	}{
+		{&SquashLogistic{}, "logistic"},
+		{&SquashLeakyRelu{}, "leaky_relu"},
+		{&SquashLeakyRelu{Slope: 0.2}, `leaky_relu{"Slope":0.2}`},
+		{&SquashElu{Alpha: 2}, `elu{"Alpha":2}`},
+		{&SquashBentIdentity{}, "bent_identity"},
+	}
+	for _, tc := range testCases {
+		name, err := SquasherName(tc.s)
+		if err != nil {
+			t.Fatalf("SquasherName(%T) threw error: %s", tc.s, err.Error())
+		}
+		if name != tc.name {
+			t.Errorf("SquasherName(%T): want %q, got %q", tc.s, tc.name, name)
+		}
+		s, err := NewSquasher(name)
+		if err != nil {
+			t.Fatalf("NewSquasher(%q) threw error: %s", name, err.Error())
+		}
+		if !reflect.DeepEqual(s, tc.s) {
+			t.Errorf("NewSquasher(%q): want %#v, got %#v", name, tc.s, s)
+		}
+	}
+
+	if _, err := NewSquasher("unknown"); err == nil {
+		t.Errorf("NewSquasher: expected error for unknown name, got nil")
+	}
+	if _, err := NewSquasher(`elu{"Alpha":`); err == nil {
+		t.Errorf("NewSquasher: expected error for invalid parameters, got nil")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("RegisterSquasher: expected panic for a name which is already registered")
+		}
+	}()
+	RegisterSquasher("tanh", func() Squasher { return &SquashTanh{} })
+}
@@ -0,0 +1,259 @@
+package automata
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// synapticNetwork is the JSON schema used by Network.toJSON in the Synaptic JavaScript library.
+// See: https://github.com/cazala/synaptic
+type synapticNetwork struct {
+	Neurons     []synapticNeuron     `json:"neurons"`
+	Connections []synapticConnection `json:"connections"`
+}
+
+type synapticNeuron struct {
+	Trace      synapticTrace   `json:"trace"`
+	State      float64         `json:"state"`
+	Old        float64         `json:"old"`
+	Activation float64         `json:"activation"`
+	Bias       float64         `json:"bias"`
+	Layer      json.RawMessage `json:"layer"` // "input", "output" or the index of the hidden layer
+	Squash     *string         `json:"squash"`
+}
+
+// synapticTrace keys are Synaptic connection and neuron IDs, which have no meaning outside of the network
+// which exported them. Synaptic itself always exports these empty.
+type synapticTrace struct {
+	Elegibility map[string]float64            `json:"elegibility"` // sic
+	Extended    map[string]map[string]float64 `json:"extended"`
+}
+
+// synapticConnection refers to neurons by their position in the neurons array. A connection where From and To
+// are the same is the self-connection of that neuron.
+type synapticConnection struct {
+	From   int     `json:"from"`
+	To     int     `json:"to"`
+	Weight float64 `json:"weight"`
+	Gater  *int    `json:"gater"`
+}
+
+const (
+	synapticLayerInput  = "input"
+	synapticLayerOutput = "output"
+)
+
+// ImportSynapticJSON reads a network in the format produced by Network.toJSON in the Synaptic JavaScript library.
+// The neurons and connections are created in the given LookupTable. The activation state of each neuron is
+// imported, but eligibility traces are not as they refer to Synaptic's internal IDs.
+func ImportSynapticJSON(table *LookupTable, r io.Reader) (*Network, error) {
+	var sn synapticNetwork
+	if err := json.NewDecoder(r).Decode(&sn); err != nil {
+		return nil, err
+	}
+
+	var inputLayer, outputLayer Layer
+	hiddenLayers := make(map[int]*Layer)
+	neurons := make([]*Neuron, len(sn.Neurons))
+	for i, sneuron := range sn.Neurons {
+		squash, err := synapticSquasher(sneuron.Squash)
+		if err != nil {
+			return nil, fmt.Errorf("ImportSynapticJSON: neuron %d: %s", i, err)
+		}
+		neuron := NewNeuron(table)
+		neuron.Squash = squash
+		neuron.State = sneuron.State
+		neuron.Old = sneuron.Old
+		neuron.Activation = sneuron.Activation
+		neuron.Bias = sneuron.Bias
+		neurons[i] = neuron
+
+		var layerName string
+		var layerIndex int
+		if err = json.Unmarshal(sneuron.Layer, &layerName); err == nil {
+			switch layerName {
+			case synapticLayerInput:
+				inputLayer.List = append(inputLayer.List, neuron)
+				continue
+			case synapticLayerOutput:
+				outputLayer.List = append(outputLayer.List, neuron)
+				continue
+			}
+			// hidden layer indexes are sometimes stringified
+			if _, err = fmt.Sscanf(layerName, "%d", &layerIndex); err != nil {
+				return nil, fmt.Errorf("ImportSynapticJSON: neuron %d has unknown layer %q", i, layerName)
+			}
+		} else if err = json.Unmarshal(sneuron.Layer, &layerIndex); err != nil {
+			return nil, fmt.Errorf("ImportSynapticJSON: neuron %d has unknown layer %s", i, string(sneuron.Layer))
+		}
+		hidden := hiddenLayers[layerIndex]
+		if hidden == nil {
+			hidden = &Layer{LookupTable: table}
+			hiddenLayers[layerIndex] = hidden
+		}
+		hidden.List = append(hidden.List, neuron)
+	}
+
+	getNeuron := func(i int) (*Neuron, error) {
+		if i < 0 || i >= len(neurons) {
+			return nil, fmt.Errorf("ImportSynapticJSON: connection references unknown neuron %d", i)
+		}
+		return neurons[i], nil
+	}
+
+	// Make all the connections first, as gating a connection depends on the inputs of the gater.
+	conns := make([]*Connection, len(sn.Connections))
+	for i, sconn := range sn.Connections {
+		from, err := getNeuron(sconn.From)
+		if err != nil {
+			return nil, err
+		}
+		to, err := getNeuron(sconn.To)
+		if err != nil {
+			return nil, err
+		}
+		weight := sconn.Weight
+		conn := from.Project(to, &weight)
+		conn.Weight = weight // self-connections are always given a weight of 1 when projected
+		conns[i] = conn
+	}
+	for i, sconn := range sn.Connections {
+		if sconn.Gater == nil {
+			continue
+		}
+		gater, err := getNeuron(*sconn.Gater)
+		if err != nil {
+			return nil, err
+		}
+		gater.Gate(conns[i])
+	}
+
+	indexes := make([]int, 0, len(hiddenLayers))
+	for index := range hiddenLayers {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	var hidden []Layer
+	for _, index := range indexes {
+		hidden = append(hidden, *hiddenLayers[index])
+	}
+	inputLayer.LookupTable = table
+	outputLayer.LookupTable = table
+	return &Network{
+		Input:  &inputLayer,
+		Hidden: hidden,
+		Output: &outputLayer,
+	}, nil
+}
+
+// ExportSynapticJSON writes the network in the format used by Network.fromJSON in the Synaptic JavaScript library.
+// Only connections between neurons in the network are exported.
+func ExportSynapticJSON(w io.Writer, n *Network) error {
+	positions := make(map[NeuronID]int)
+	sn := synapticNetwork{
+		Neurons:     []synapticNeuron{},
+		Connections: []synapticConnection{},
+	}
+	layers := n.layers()
+	for i, layer := range layers {
+		var layerName interface{}
+		switch i {
+		case 0:
+			layerName = synapticLayerInput
+		case len(layers) - 1:
+			layerName = synapticLayerOutput
+		default:
+			layerName = i - 1
+		}
+		if layer.Activation != LayerActivationNeurons {
+			return fmt.Errorf("ExportSynapticJSON: layer %d uses an activation which Synaptic does not support", i)
+		}
+		layerJSON, err := json.Marshal(layerName)
+		if err != nil {
+			return err
+		}
+		for _, neuron := range layer.List {
+			squash, err := synapticSquashName(neuron.Squash)
+			if err != nil {
+				return fmt.Errorf("ExportSynapticJSON: neuron %d: %s", neuron.ID, err)
+			}
+			positions[neuron.ID] = len(sn.Neurons)
+			sn.Neurons = append(sn.Neurons, synapticNeuron{
+				Trace: synapticTrace{
+					Elegibility: map[string]float64{},
+					Extended:    map[string]map[string]float64{},
+				},
+				State:      neuron.State,
+				Old:        neuron.Old,
+				Activation: neuron.Activation,
+				Bias:       neuron.Bias,
+				Layer:      layerJSON,
+				Squash:     &squash,
+			})
+		}
+	}
+
+	addConnection := func(conn *Connection) {
+		from, fromOK := positions[conn.From.ID]
+		to, toOK := positions[conn.To.ID]
+		if !fromOK || !toOK {
+			return
+		}
+		sconn := synapticConnection{
+			From:   from,
+			To:     to,
+			Weight: conn.Weight,
+		}
+		if conn.Gater != nil {
+			if gater, ok := positions[conn.Gater.ID]; ok {
+				sconn.Gater = &gater
+			}
+		}
+		sn.Connections = append(sn.Connections, sconn)
+	}
+	for _, layer := range layers {
+		for _, neuron := range layer.List {
+			if neuron.Self.Weight != 0 {
+				addConnection(neuron.Self)
+			}
+			for _, connID := range neuron.Projected {
+				addConnection(n.LookupTable().GetConnection(connID))
+			}
+		}
+	}
+
+	return json.NewEncoder(w).Encode(sn)
+}
+
+func synapticSquasher(name *string) (Squasher, error) {
+	if name == nil {
+		return nil, fmt.Errorf("missing squash function")
+	}
+	switch *name {
+	case "LOGISTIC":
+		return &SquashLogistic{}, nil
+	case "TANH":
+		return &SquashTanh{}, nil
+	case "IDENTITY":
+		return &SquashIdentity{}, nil
+	case "RELU":
+		return &SquashRelu{}, nil
+	}
+	return nil, fmt.Errorf("unsupported squash function %q", *name)
+}
+
+func synapticSquashName(s Squasher) (string, error) {
+	switch s.(type) {
+	case *SquashLogistic:
+		return "LOGISTIC", nil
+	case *SquashTanh:
+		return "TANH", nil
+	case *SquashIdentity:
+		return "IDENTITY", nil
+	case *SquashRelu:
+		return "RELU", nil
+	}
+	return "", fmt.Errorf("squasher type %T has no Synaptic equivalent", s)
+}
@@ -0,0 +1,82 @@
+package automata_test
+
+import (
+	"bytes"
+	"github.com/Kegsay/automata"
+	"math"
+	"strings"
+	"testing"
+)
+
+// A 2-2-1 perceptron as exported by Synaptic's Network.toJSON, with a gated self-connection on the output neuron.
+const synapticPerceptron = `{
+	"neurons": [
+		{"trace": {"elegibility": {}, "extended": {}}, "state": 0, "old": 0, "activation": 0, "bias": 0, "layer": "input", "squash": "LOGISTIC"},
+		{"trace": {"elegibility": {}, "extended": {}}, "state": 0, "old": 0, "activation": 0, "bias": 0, "layer": "input", "squash": "LOGISTIC"},
+		{"trace": {"elegibility": {}, "extended": {}}, "state": 0, "old": 0, "activation": 0, "bias": 0.1, "layer": 0, "squash": "TANH"},
+		{"trace": {"elegibility": {}, "extended": {}}, "state": 0, "old": 0, "activation": 0, "bias": -0.2, "layer": 0, "squash": "LOGISTIC"},
+		{"trace": {"elegibility": {}, "extended": {}}, "state": 0, "old": 0, "activation": 0, "bias": 0.3, "layer": "output", "squash": "IDENTITY"}
+	],
+	"connections": [
+		{"from": 0, "to": 2, "weight": 0.5, "gater": null},
+		{"from": 0, "to": 3, "weight": -0.5, "gater": null},
+		{"from": 1, "to": 2, "weight": 0.25, "gater": null},
+		{"from": 1, "to": 3, "weight": 0.75, "gater": null},
+		{"from": 2, "to": 4, "weight": 1.5, "gater": null},
+		{"from": 3, "to": 4, "weight": -1, "gater": null},
+		{"from": 4, "to": 4, "weight": 0.5, "gater": 3}
+	]
+}`
+
+func TestImportSynapticJSON(t *testing.T) {
+	testLookupTable := &automata.LookupTable{}
+	network, err := automata.ImportSynapticJSON(testLookupTable, strings.NewReader(synapticPerceptron))
+	if err != nil {
+		t.Fatalf("ImportSynapticJSON threw error: %s", err.Error())
+	}
+	if len(network.Input.List) != 2 || len(network.Hidden) != 1 || len(network.Hidden[0].List) != 2 || len(network.Output.List) != 1 {
+		t.Fatalf("ImportSynapticJSON: wrong layer sizes")
+	}
+
+	// work out the expected output by hand
+	h0 := math.Tanh(0.1 + 1*0.5 + 1*0.25)
+	h1 := 1 / (1 + math.Exp(-(-0.2 + 1*-0.5 + 1*0.75)))
+	state := 0.3 + h0*1.5 + h1*-1
+	for i := 0; i < 2; i++ {
+		output, err := network.Activate([]float64{1, 1})
+		if err != nil {
+			t.Fatalf("Activate threw error: %s", err.Error())
+		}
+		if math.Abs(output[0]-state) > 1e-12 {
+			t.Errorf("Activate #%d: want %v, got %v", i, state, output[0])
+		}
+		state = 0.3 + h0*1.5 + h1*-1 + h1*0.5*state // the self-connection is gated by the second hidden neuron
+	}
+}
+
+func TestExportSynapticJSON(t *testing.T) {
+	testLookupTable := &automata.LookupTable{}
+	lstm := automata.NewLSTM(testLookupTable, 2, []int{3}, 1)
+	var buf bytes.Buffer
+	if err := automata.ExportSynapticJSON(&buf, lstm); err != nil {
+		t.Fatalf("ExportSynapticJSON threw error: %s", err.Error())
+	}
+	imported, err := automata.ImportSynapticJSON(&automata.LookupTable{}, &buf)
+	if err != nil {
+		t.Fatalf("ImportSynapticJSON threw error: %s", err.Error())
+	}
+	for _, input := range [][]float64{{0, 0}, {0, 1}, {1, 1}, {1, 0}} {
+		want, _ := lstm.Activate(input)
+		got, _ := imported.Activate(input)
+		if math.Abs(want[0]-got[0]) > 1e-12 {
+			t.Errorf("Activate(%v): want %v, got %v", input, want[0], got[0])
+		}
+	}
+}
+
+func TestImportSynapticJSONUnknownSquash(t *testing.T) {
+	input := `{"neurons": [{"layer": "input", "squash": "HLIM"}], "connections": []}`
+	if _, err := automata.ImportSynapticJSON(&automata.LookupTable{}, strings.NewReader(input)); err == nil {
+		t.Errorf("ImportSynapticJSON: expected error for unsupported squash, got nil")
+	}
+}
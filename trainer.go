@@ -1,42 +1,301 @@
 package automata
 
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"time"
+)
+
 type Trainer struct {
 	Network      Networker
 	LearnRate    float64
 	Iterations   int
 	MaxErrorRate float64
 	CostFunction Coster
+	// Optimizer used to update weights and biases. If nil, the Optimizer already set on the network's
+	// LookupTable is used, which defaults to plain stochastic gradient descent.
+	Optimizer Optimizer
+	// BatchSize is the number of samples to propagate before updating weights and biases, using the average
+	// gradient of the samples in the batch. If 0 or 1, weights are updated after every sample. Use FullBatch
+	// to update once per iteration over the whole training set.
+	BatchSize int
+	// Replicas is the number of copies of the network which train on each batch concurrently. Each batch is split
+	// into a shard for each replica, the gradients of every shard are added up and applied to the network, then the
+	// new weights and biases are copied to the replicas. Each replica keeps its own neuron state, so recurrent
+	// networks see each shard as a separate sequence. Requires a BatchSize of more than 1, and the Network must be
+	// a *Network or *Hopfield. If 0 or 1, the network is trained on the calling goroutine.
+	Replicas int
+	// LearnRateSchedule varies the learning rate for each iteration, starting from LearnRate. If nil, LearnRate
+	// is used for every iteration.
+	LearnRateSchedule LearnRateSchedule
+	// OnIteration is called after every iteration with the iteration number (starting from 0) and the error rate
+	// for that iteration. Return false to stop training.
+	OnIteration func(iter int, errRate float64) bool
+	// LogEvery logs the error rate every N iterations using the standard logger. If 0, nothing is logged.
+	LogEvery int
+	// Shuffle controls the order the training set is used in each iteration. By default it is used in order.
+	Shuffle ShuffleMode
+	// ShuffleSource is the source of randomness used for shuffling. If nil, a source with a fixed seed is used so
+	// training is repeatable.
+	ShuffleSource rand.Source
+	// Sequential marks the training set as a series of sequences whose order must be kept, such as when training
+	// an LSTM. Each value is the length of a sequence, in order, and they must add up to the size of the training
+	// set. When shuffling, the order of the sequences is shuffled but the samples within each sequence are not.
+	Sequential []int
+	// ValidationSet is evaluated after every iteration without training on it. The error rates are recorded in
+	// TrainResult.ValidationErrorHistory.
+	ValidationSet []TrainSet
+	// EarlyStopping stops training once the error rate of the ValidationSet stops improving. If nil, training
+	// only stops when Iterations or MaxErrorRate is reached.
+	EarlyStopping *EarlyStopping
+	// Checkpoint writes checkpoints during training which can be resumed with ResumeTrainer. If nil, no
+	// checkpoints are written.
+	Checkpoint *Checkpoint
+
+	resume *checkpointFile // the checkpoint to continue from on the next call to Train
+}
+
+// TrainResult describes the outcome of training.
+type TrainResult struct {
+	// Iterations is the number of iterations which were run.
+	Iterations int
+	// Error is the error rate of the final iteration.
+	Error float64
+	// ErrorHistory is the error rate of each iteration.
+	ErrorHistory []float64
+	// ValidationErrorHistory is the error rate of the validation set after each iteration, if there is one.
+	ValidationErrorHistory []float64
+	// Elapsed is how long training took.
+	Elapsed time.Duration
+	// ReachedMaxErrorRate is true if training stopped because the error rate fell below Trainer.MaxErrorRate.
+	ReachedMaxErrorRate bool
+	// StoppedEarly is true if training stopped because the validation error rate stopped improving.
+	StoppedEarly bool
 }
 
+// FullBatch can be used as the Trainer.BatchSize to use the whole training set as a single batch.
+const FullBatch = -1
+
 type TrainSet struct {
 	Input  []float64
 	Output []float64
 }
 
-func (t *Trainer) Train(trainingSet []TrainSet) error {
-	// TODO: Cross-validation support
-	for i := 0; i < t.Iterations; i++ {
-		errorSum, err := t.trainSet(trainingSet, t.LearnRate, t.CostFunction)
+// Train the network on the training set.
+func (t *Trainer) Train(trainingSet []TrainSet) (*TrainResult, error) {
+	return t.train(context.Background(), trainingSet)
+}
+
+// TrainContext trains the network on the training set until training finishes or the context is done. The
+// context is checked before each sample is trained. If the context is done, the result so far is returned
+// along with ctx.Err(). The network is always left in a consistent state: when training in batches, gradients
+// from an incomplete batch are discarded rather than applied.
+func (t *Trainer) TrainContext(ctx context.Context, trainingSet []TrainSet) (*TrainResult, error) {
+	return t.train(ctx, trainingSet)
+}
+
+func (t *Trainer) train(ctx context.Context, trainingSet []TrainSet) (*TrainResult, error) {
+	var stopper *earlyStopper
+	if t.EarlyStopping != nil {
+		if len(t.ValidationSet) == 0 {
+			return nil, fmt.Errorf("Train: EarlyStopping requires a ValidationSet")
+		}
+		stopper = newEarlyStopper(t.EarlyStopping)
+	}
+	if t.Replicas > 1 && t.BatchSize >= 0 && t.BatchSize <= 1 {
+		return nil, fmt.Errorf("Train: Replicas requires a BatchSize of more than 1")
+	}
+	if weighted, ok := t.CostFunction.(*WeightedCost); ok {
+		for _, set := range [][]TrainSet{trainingSet, t.ValidationSet} {
+			for _, s := range set {
+				if err := weighted.check(len(s.Output)); err != nil {
+					return nil, fmt.Errorf("Train: %s", err)
+				}
+			}
+		}
+	}
+	table := networkTable(t.Network)
+	if table == nil {
+		if t.Optimizer != nil || t.BatchSize < 0 || t.BatchSize > 1 || t.EarlyStopping != nil {
+			return nil, fmt.Errorf("Train: Optimizer, BatchSize and EarlyStopping need a network with a LookupTable, but %T does not have one", t.Network)
+		}
+	} else {
+		if t.Optimizer != nil {
+			table.Optimizer = t.Optimizer
+		}
+		// the cost function only applies while training, so propagating directly afterwards is unaffected
+		defer func(cost Coster) { table.CostFunction = cost }(table.CostFunction)
+		table.CostFunction = t.CostFunction
+	}
+	var workers *replicas
+	if t.Replicas > 1 {
+		var err error
+		if workers, err = newReplicas(t.Network, t.Replicas, t.CostFunction); err != nil {
+			return nil, err
+		}
+	}
+	var checkpoints *checkpointer
+	if t.Checkpoint != nil {
+		checkpoints = &checkpointer{Checkpoint: t.Checkpoint, last: time.Now(), best: math.Inf(1)}
+	}
+	var shuffle *shuffler
+	if t.Shuffle == ShuffleEpoch {
+		var draws int64
+		if t.resume != nil {
+			draws = t.resume.ShuffleDraws
+		}
+		var err error
+		if shuffle, err = newShuffler(trainingSet, t.Sequential, t.ShuffleSource, draws); err != nil {
+			return nil, err
+		}
+	}
+	result := &TrainResult{}
+	first := 0
+	errRate := math.Inf(1)
+	if t.resume != nil {
+		first, errRate = t.resumeResult(result, stopper)
+		if checkpoints != nil {
+			checkpoints.best = t.resume.Best
+		}
+		t.resume = nil
+	}
+	start := time.Now().Add(-result.Elapsed)
+	defer func() {
+		result.Elapsed = time.Since(start)
+		if stopper != nil && stopper.params.weights != nil {
+			table.restoreParameters(stopper.params)
+		}
+	}()
+
+	for i := first; i < t.Iterations; i++ {
+		set := trainingSet
+		if shuffle != nil {
+			set = shuffle.shuffle()
+		}
+		errorSum, err := t.trainSet(ctx, set, t.learnRate(i, errRate), t.CostFunction, workers)
 		if err != nil {
-			return err
+			return result, err
+		}
+		errRate = errorSum / float64(len(trainingSet))
+		result.Iterations++
+		result.Error = errRate
+		result.ErrorHistory = append(result.ErrorHistory, errRate)
+
+		if t.LogEvery > 0 && (i+1)%t.LogEvery == 0 {
+			log.Printf("Trainer: iteration %d: error rate %v", i+1, errRate)
+		}
+
+		score := errRate
+		stop := false
+		if len(t.ValidationSet) > 0 {
+			if score, err = t.evaluate(ctx, t.ValidationSet); err != nil {
+				return result, err
+			}
+			result.ValidationErrorHistory = append(result.ValidationErrorHistory, score)
+			if stopper != nil {
+				var improved bool
+				if improved, stop = stopper.update(score); improved {
+					stopper.params = table.saveParameters()
+				}
+			}
+		}
+		if checkpoints != nil && checkpoints.due(i+1) {
+			if err = checkpoints.write(t, result, time.Since(start), score, stopper, shuffle); err != nil {
+				return result, err
+			}
+		}
+
+		if t.OnIteration != nil && !t.OnIteration(i, errRate) {
+			return result, nil
+		}
+		if stop {
+			result.StoppedEarly = true
+			return result, nil
 		}
-		errRate := errorSum / float64(len(trainingSet))
 		if errRate < t.MaxErrorRate {
-			return nil
+			result.ReachedMaxErrorRate = true
+			return result, nil
 		}
 	}
-	return nil
+	return result, nil
 }
 
-func (t *Trainer) trainSet(set []TrainSet, rate float64, coster Coster) (float64, error) {
+// evaluate the network on the given set without training it, returning the average cost.
+func (t *Trainer) evaluate(ctx context.Context, set []TrainSet) (float64, error) {
 	var errorSum float64
 	for _, s := range set {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		output, err := t.Network.Activate(s.Input)
+		if err != nil {
+			return 0, err
+		}
+		errorSum += t.CostFunction.Cost(s.Output, output)
+	}
+	return errorSum / float64(len(set)), nil
+}
+
+// learnRate returns the learning rate to use for the given iteration.
+func (t *Trainer) learnRate(iteration int, errRate float64) float64 {
+	if t.LearnRateSchedule == nil {
+		return t.LearnRate
+	}
+	return t.LearnRateSchedule.LearnRate(iteration, t.LearnRate, errRate)
+}
+
+// trainSet trains the network on every sample in the set, returning the total cost. If 'workers' is set, each
+// batch is trained by the replicas instead.
+func (t *Trainer) trainSet(ctx context.Context, set []TrainSet, rate float64, coster Coster, workers *replicas) (float64, error) {
+	batchSize := t.BatchSize
+	if batchSize < 0 || batchSize > len(set) {
+		batchSize = len(set)
+	}
+	table := networkTable(t.Network)
+	if batchSize > 1 {
+		table.AccumulateGradients = true
+		defer func() {
+			table.AccumulateGradients = false
+			table.ResetGradients() // discard the partial batch if we return early
+		}()
+	}
+
+	var errorSum float64
+	if workers != nil {
+		for start := 0; start < len(set); start += batchSize {
+			end := start + batchSize
+			if end > len(set) {
+				end = len(set)
+			}
+			cost, err := workers.trainBatch(ctx, table, set[start:end], rate, coster)
+			if err != nil {
+				return 0, err
+			}
+			errorSum += cost
+			table.ApplyGradients(rate, end-start)
+			workers.sync(table)
+		}
+		return errorSum, nil
+	}
+	for i, s := range set {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
 		actualOutput, err := t.Network.Activate(s.Input)
 		if err != nil {
 			return 0, err
 		}
-		t.Network.Propagate(rate, s.Output)
+		if err = t.Network.Propagate(rate, s.Output); err != nil {
+			return 0, err
+		}
 		errorSum += coster.Cost(s.Output, actualOutput)
+
+		if batchSize > 1 && ((i+1)%batchSize == 0 || i == len(set)-1) {
+			table.ApplyGradients(rate, i%batchSize+1)
+		}
 	}
 	return errorSum, nil
 }
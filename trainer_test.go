@@ -0,0 +1,146 @@
+package automata
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTrainResult(t *testing.T) {
+	network, sets := orNetwork()
+	trainer := Trainer{
+		Network:      network,
+		MaxErrorRate: 0.01,
+		LearnRate:    0.5,
+		Iterations:   5000,
+		CostFunction: &MeanSquaredErrorCost{},
+	}
+	result, err := trainer.Train(sets)
+	if err != nil {
+		t.Fatalf("trainer.Train threw error: %s", err.Error())
+	}
+	if !result.ReachedMaxErrorRate {
+		t.Errorf("want ReachedMaxErrorRate, got %+v", result)
+	}
+	if result.Iterations != len(result.ErrorHistory) || result.Iterations == 0 {
+		t.Fatalf("want %d iterations, got %d", len(result.ErrorHistory), result.Iterations)
+	}
+	if result.Error != result.ErrorHistory[result.Iterations-1] || result.Error >= 0.01 {
+		t.Errorf("want final error below 0.01 matching the history, got %v", result.Error)
+	}
+	if result.Elapsed <= 0 {
+		t.Errorf("want positive Elapsed, got %v", result.Elapsed)
+	}
+	if cost := network.LookupTable().CostFunction; cost != nil {
+		t.Errorf("want the LookupTable's CostFunction to be put back after training, got %T", cost)
+	}
+}
+
+func TestTrainOnIteration(t *testing.T) {
+	network, sets := orNetwork()
+	var iterations []int
+	trainer := Trainer{
+		Network:      network,
+		LearnRate:    0.5,
+		Iterations:   5000,
+		CostFunction: &MeanSquaredErrorCost{},
+		OnIteration: func(iter int, errRate float64) bool {
+			iterations = append(iterations, iter)
+			return iter < 2
+		},
+	}
+	result, err := trainer.Train(sets)
+	if err != nil {
+		t.Fatalf("trainer.Train threw error: %s", err.Error())
+	}
+	if result.Iterations != 3 || len(iterations) != 3 || iterations[2] != 2 {
+		t.Errorf("want training to stop after 3 iterations, got %d (callbacks: %v)", result.Iterations, iterations)
+	}
+	if result.ReachedMaxErrorRate {
+		t.Errorf("want ReachedMaxErrorRate to be false when stopped by OnIteration")
+	}
+}
+
+// cancelCoster cancels a context after a number of samples have been costed.
+type cancelCoster struct {
+	MeanSquaredErrorCost
+	cancel  context.CancelFunc
+	samples int
+}
+
+func (c *cancelCoster) Cost(target, output []float64) float64 {
+	if c.samples--; c.samples == 0 {
+		c.cancel()
+	}
+	return c.MeanSquaredErrorCost.Cost(target, output)
+}
+
+func TestTrainContext(t *testing.T) {
+	network, sets := orNetwork()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	trainer := Trainer{
+		Network:      network,
+		LearnRate:    0.5,
+		Iterations:   5000,
+		CostFunction: &cancelCoster{cancel: cancel, samples: 2*len(sets) + 2},
+		BatchSize:    FullBatch,
+	}
+	var weights []float64
+	trainer.OnIteration = func(iter int, errRate float64) bool {
+		weights = network.LookupTable().saveParameters().weights
+		return true
+	}
+	result, err := trainer.TrainContext(ctx, sets)
+	if err != context.Canceled {
+		t.Fatalf("want context.Canceled, got %v", err)
+	}
+	if result == nil || result.Iterations != 2 || len(result.ErrorHistory) != 2 {
+		t.Fatalf("want a result with 2 complete iterations, got %+v", result)
+	}
+	// the third iteration was cancelled part way through its batch, so no gradients should have been applied
+	table := network.LookupTable()
+	for i, w := range table.saveParameters().weights {
+		if w != weights[i] {
+			t.Errorf("weight %d changed after cancellation: want %v, got %v", i, weights[i], w)
+		}
+	}
+	if table.AccumulateGradients {
+		t.Errorf("LookupTable is still accumulating gradients after cancellation")
+	}
+}
+
+// plainNetworker only implements Networker, so the Trainer cannot get its LookupTable.
+type plainNetworker struct {
+	network *Network
+}
+
+func (n plainNetworker) Activate(input []float64) ([]float64, error) {
+	return n.network.Activate(input)
+}
+
+func (n plainNetworker) Propagate(rate float64, target []float64) error {
+	return n.network.Propagate(rate, target)
+}
+
+func TestTrainWithoutLookupTable(t *testing.T) {
+	network, sets := orNetwork()
+	trainer := Trainer{
+		Network:      plainNetworker{network},
+		MaxErrorRate: 0.01,
+		LearnRate:    0.5,
+		Iterations:   5000,
+		CostFunction: &MeanSquaredErrorCost{},
+	}
+	result, err := trainer.Train(sets)
+	if err != nil {
+		t.Fatalf("trainer.Train threw error: %s", err.Error())
+	}
+	if !result.ReachedMaxErrorRate {
+		t.Errorf("want ReachedMaxErrorRate, got %+v", result)
+	}
+
+	trainer.BatchSize = 2
+	if _, err = trainer.Train(sets); err == nil {
+		t.Errorf("Train: expected error batching without a LookupTable, got nil")
+	}
+}
@@ -0,0 +1,139 @@
+package automata
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// EarlyStopping stops training once the error rate of Trainer.ValidationSet stops improving. Whenever training
+// stops, including when Trainer.MaxErrorRate is reached, Trainer.OnIteration returns false, the context is done or
+// an error is returned, the weights and biases from the iteration with the best validation error rate so far are
+// restored.
+type EarlyStopping struct {
+	// Patience is the number of iterations the validation error rate can fail to improve by more than MinDelta
+	// before training stops. If 0, training never stops early, but the best weights and biases are still restored.
+	Patience int
+	// MinDelta is how much the validation error rate must fall below the best so far to count as an improvement.
+	MinDelta float64
+}
+
+// earlyStopper tracks the validation error rate for a single training run.
+type earlyStopper struct {
+	*EarlyStopping
+	best      float64
+	sinceBest int
+	params    parameters // from the best iteration
+}
+
+func newEarlyStopper(es *EarlyStopping) *earlyStopper {
+	return &earlyStopper{EarlyStopping: es, best: math.Inf(1)}
+}
+
+// update the stopper with the validation error rate of an iteration. Returns whether the error rate improved on
+// the best so far, and whether training should stop.
+func (e *earlyStopper) update(validationErr float64) (improved, stop bool) {
+	if validationErr < e.best-e.MinDelta {
+		e.best = validationErr
+		e.sinceBest = 0
+		return true, false
+	}
+	e.sinceBest++
+	return false, e.Patience > 0 && e.sinceBest >= e.Patience
+}
+
+// CrossValidation configures how the training set is split into training and validation sets by
+// Trainer.CrossValidate.
+type CrossValidation struct {
+	// Folds is the number of folds to use for k-fold cross-validation. The training set is split into this many
+	// equal parts, and the network is trained once per fold, using that fold as the validation set and the
+	// remaining folds as the training set. If less than 2, hold-out validation is used instead.
+	Folds int
+	// HoldOut is the fraction of the training set to use as the validation set for hold-out validation, e.g 0.2.
+	// The validation set is taken from the end of the training set. Only used if Folds is less than 2.
+	HoldOut float64
+	// Patience is the number of iterations the validation error can fail to improve by more than MinDelta before
+	// training stops. If 0, training only stops when Trainer.Iterations or Trainer.MaxErrorRate is reached.
+	Patience int
+	MinDelta float64
+	// LearnRateSchedule is called once per fold to make the learning rate schedule for that fold, so that
+	// schedules which keep state between iterations start afresh for every fold. If nil, the learning rate is
+	// Trainer.LearnRate for every iteration.
+	LearnRateSchedule func() LearnRateSchedule
+}
+
+// CrossValidate trains the network using either k-fold or hold-out validation. The cost of the validation set is
+// evaluated after each iteration. The result of training on each fold is returned, in order.
+//
+// With k-fold validation, every fold starts training from the weights and biases the network had when
+// CrossValidate was called. Only the weights and biases are reset: the state and traces of every neuron carry over
+// from one fold to the next. The network is left with the best weights and biases from training on the final fold.
+// Trainer.ValidationSet, Trainer.EarlyStopping and Trainer.Checkpoint are not used, and Trainer.LearnRateSchedule
+// must be nil as it would be shared by every fold: use CrossValidation.LearnRateSchedule instead. Sequential
+// training sets cannot be shuffled, as the folds do not line up with the sequences.
+func (t *Trainer) CrossValidate(sets []TrainSet, cv CrossValidation) ([]*TrainResult, error) {
+	if t.Shuffle != ShuffleOff && t.Sequential != nil {
+		return nil, fmt.Errorf("CrossValidate: cannot shuffle Sequential training sets")
+	}
+	if t.LearnRateSchedule != nil {
+		return nil, fmt.Errorf("CrossValidate: use CrossValidation.LearnRateSchedule to make a schedule for each fold, not Trainer.LearnRateSchedule")
+	}
+	folds, err := cv.split(sets)
+	if err != nil {
+		return nil, err
+	}
+	table := networkTable(t.Network)
+	if table == nil {
+		return nil, fmt.Errorf("CrossValidate: %T does not have a LookupTable to reset between folds", t.Network)
+	}
+	initial := table.saveParameters()
+	var results []*TrainResult
+	for i, fold := range folds {
+		if i > 0 {
+			table.restoreParameters(initial)
+		}
+		trainer := *t
+		if cv.LearnRateSchedule != nil {
+			trainer.LearnRateSchedule = cv.LearnRateSchedule()
+		}
+		trainer.ValidationSet = fold[1]
+		trainer.EarlyStopping = &EarlyStopping{Patience: cv.Patience, MinDelta: cv.MinDelta}
+		trainer.Checkpoint = nil
+		trainer.resume = nil
+		result, err := trainer.train(context.Background(), fold[0])
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// split the sets into pairs of training and validation sets.
+func (cv *CrossValidation) split(sets []TrainSet) ([][2][]TrainSet, error) {
+	if cv.Folds < 2 {
+		if cv.HoldOut <= 0 || cv.HoldOut >= 1 {
+			return nil, fmt.Errorf("CrossValidate: HoldOut must be between 0 and 1, got %v", cv.HoldOut)
+		}
+		numValidation := int(float64(len(sets)) * cv.HoldOut)
+		if numValidation == 0 || numValidation == len(sets) {
+			return nil, fmt.Errorf("CrossValidate: cannot hold out %v of %d training sets", cv.HoldOut, len(sets))
+		}
+		split := len(sets) - numValidation
+		return [][2][]TrainSet{{sets[:split], sets[split:]}}, nil
+	}
+
+	if len(sets) < cv.Folds {
+		return nil, fmt.Errorf("CrossValidate: cannot split %d training sets into %d folds", len(sets), cv.Folds)
+	}
+	var folds [][2][]TrainSet
+	for i := 0; i < cv.Folds; i++ {
+		start := i * len(sets) / cv.Folds
+		end := (i + 1) * len(sets) / cv.Folds
+		var training []TrainSet
+		training = append(training, sets[:start]...)
+		training = append(training, sets[end:]...)
+		folds = append(folds, [2][]TrainSet{training, sets[start:end]})
+	}
+	return folds, nil
+}
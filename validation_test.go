@@ -0,0 +1,176 @@
+package automata
+
+import (
+	"reflect"
+	"testing"
+)
+
+func orNetwork() (*Network, []TrainSet) {
+	table := &LookupTable{}
+	inputLayer := NewLayer(table, 2)
+	outputLayer := NewLayer(table, 1)
+	inputLayer.Project(&outputLayer, LayerTypeAuto)
+	network := &Network{
+		Input:  &inputLayer,
+		Output: &outputLayer,
+	}
+	sets := []TrainSet{
+		{[]float64{0, 0}, []float64{0}},
+		{[]float64{0, 1}, []float64{1}},
+		{[]float64{1, 0}, []float64{1}},
+		{[]float64{1, 1}, []float64{1}},
+	}
+	return network, sets
+}
+
+func TestCrossValidationSplit(t *testing.T) {
+	sets := make([]TrainSet, 10)
+	for i := range sets {
+		sets[i].Input = []float64{float64(i)}
+	}
+	folds, err := (&CrossValidation{Folds: 3}).split(sets)
+	if err != nil {
+		t.Fatalf("split threw error: %s", err.Error())
+	}
+	wantSizes := []int{3, 3, 4}
+	seen := make(map[float64]bool)
+	for i, fold := range folds {
+		if len(fold[1]) != wantSizes[i] || len(fold[0])+len(fold[1]) != len(sets) {
+			t.Errorf("fold %d: want %d validation sets, got %d training and %d validation", i, wantSizes[i], len(fold[0]), len(fold[1]))
+		}
+		for _, s := range fold[1] {
+			seen[s.Input[0]] = true
+		}
+	}
+	if len(seen) != len(sets) {
+		t.Errorf("every set should be used for validation once, got %d of %d", len(seen), len(sets))
+	}
+
+	folds, err = (&CrossValidation{HoldOut: 0.2}).split(sets)
+	if err != nil {
+		t.Fatalf("split threw error: %s", err.Error())
+	}
+	if len(folds) != 1 || len(folds[0][0]) != 8 || folds[0][1][0].Input[0] != 8 {
+		t.Errorf("hold-out: want the last 2 sets held out, got %v", folds)
+	}
+
+	if _, err = (&CrossValidation{}).split(sets); err == nil {
+		t.Errorf("split: expected error with no folds or hold-out, got nil")
+	}
+	if _, err = (&CrossValidation{Folds: 11}).split(sets); err == nil {
+		t.Errorf("split: expected error with more folds than sets, got nil")
+	}
+}
+
+func TestCrossValidate(t *testing.T) {
+	network, sets := orNetwork()
+	trainer := Trainer{
+		Network:      network,
+		LearnRate:    0.1,
+		Iterations:   50,
+		CostFunction: &MeanSquaredErrorCost{},
+	}
+	var schedules []*ReduceOnPlateauSchedule
+	cv := CrossValidation{Folds: 4, LearnRateSchedule: func() LearnRateSchedule {
+		schedules = append(schedules, &ReduceOnPlateauSchedule{Factor: 0.1})
+		return schedules[len(schedules)-1]
+	}}
+	results, err := trainer.CrossValidate(sets, cv)
+	if err != nil {
+		t.Fatalf("CrossValidate threw error: %s", err.Error())
+	}
+	if len(results) != 4 {
+		t.Fatalf("want 4 fold results, got %d", len(results))
+	}
+	for i, result := range results {
+		if len(result.ErrorHistory) != 50 || len(result.ValidationErrorHistory) != 50 {
+			t.Errorf("fold %d: want 50 iterations, got %d training and %d validation errors", i, len(result.ErrorHistory), len(result.ValidationErrorHistory))
+		}
+	}
+	if len(schedules) != 4 {
+		t.Errorf("want a schedule to be made for each of the 4 folds, got %d", len(schedules))
+	}
+	for i, s := range schedules {
+		if !s.started {
+			t.Errorf("fold %d: want the fold's schedule to be used", i)
+		}
+	}
+
+	trainer.LearnRateSchedule = &ReduceOnPlateauSchedule{Factor: 0.1}
+	if _, err = trainer.CrossValidate(sets, cv); err == nil {
+		t.Errorf("CrossValidate: expected error with a Trainer.LearnRateSchedule shared by every fold, got nil")
+	}
+}
+
+func TestCrossValidatePatience(t *testing.T) {
+	network, sets := orNetwork()
+	trainer := Trainer{
+		Network:      network,
+		LearnRate:    0.1,
+		Iterations:   1000,
+		CostFunction: &MeanSquaredErrorCost{},
+	}
+	// validation error can never improve by 1, so this will stop once patience runs out
+	results, err := trainer.CrossValidate(sets, CrossValidation{HoldOut: 0.25, Patience: 5, MinDelta: 1})
+	if err != nil {
+		t.Fatalf("CrossValidate threw error: %s", err.Error())
+	}
+	if len(results) != 1 || results[0].Iterations != 6 {
+		t.Errorf("want training to stop after 6 iterations, got %v", results)
+	}
+}
+
+func TestEarlyStopping(t *testing.T) {
+	network, sets := orNetwork()
+	// train on the opposite of the validation set so the validation error gets worse
+	var inverted []TrainSet
+	for _, s := range sets {
+		inverted = append(inverted, TrainSet{s.Input, []float64{1 - s.Output[0]}})
+	}
+	var params []parameters
+	trainer := Trainer{
+		Network:       network,
+		LearnRate:     0.5,
+		Iterations:    1000,
+		CostFunction:  &MeanSquaredErrorCost{},
+		ValidationSet: sets,
+		EarlyStopping: &EarlyStopping{Patience: 3},
+		OnIteration: func(iter int, errRate float64) bool {
+			params = append(params, network.LookupTable().saveParameters())
+			return true
+		},
+	}
+	result, err := trainer.Train(inverted)
+	if err != nil {
+		t.Fatalf("trainer.Train threw error: %s", err.Error())
+	}
+	if !result.StoppedEarly || result.Iterations != 4 {
+		t.Fatalf("want training to stop early after 4 iterations, got %d (validation errors: %v)", result.Iterations, result.ValidationErrorHistory)
+	}
+	got := network.LookupTable().saveParameters()
+	if !reflect.DeepEqual(got, params[0]) {
+		t.Errorf("want parameters from the first iteration %v, got %v", params[0], got)
+	}
+
+	// the best parameters are also restored when training is stopped some other way
+	params = nil
+	trainer.EarlyStopping = &EarlyStopping{Patience: 10}
+	trainer.OnIteration = func(iter int, errRate float64) bool {
+		params = append(params, network.LookupTable().saveParameters())
+		return iter < 2
+	}
+	if result, err = trainer.Train(inverted); err != nil {
+		t.Fatalf("trainer.Train threw error: %s", err.Error())
+	}
+	if result.StoppedEarly || result.Iterations != 3 {
+		t.Fatalf("want OnIteration to stop training after 3 iterations, got %d", result.Iterations)
+	}
+	if got = network.LookupTable().saveParameters(); !reflect.DeepEqual(got, params[0]) {
+		t.Errorf("OnIteration: want parameters from the first iteration %v, got %v", params[0], got)
+	}
+
+	trainer.ValidationSet = nil
+	if _, err = trainer.Train(inverted); err == nil {
+		t.Errorf("Train: expected error using EarlyStopping without a ValidationSet, got nil")
+	}
+}